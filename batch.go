@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Delimiters around each file of a batched reply.
+const (
+	batchFileStart = "<<<FILE "
+	batchFileEnd   = "<<<END FILE>>>"
+)
+
+// batchable reports whether filePath may be generated together with other
+// files in one request. Large files always get their own, as do all files
+// under a custom code template and UI files with a theme, whose prompts
+// the batch prompt cannot reproduce.
+func (r *generationRun) batchable(filePath string) bool {
+	a := r.agent
+	return a.BatchFiles > 1 && !isLargeFile(r.spec, filePath) &&
+		a.CodeTemplate == defaultCodeTmpl && a.themeInstruction(r.spec, filePath) == ""
+}
+
+// batchableWith reports whether filePath can join a batch started with
+// first: batched files share one model and system prompt.
+func (r *generationRun) batchableWith(first, filePath string) bool {
+	a := r.agent
+	return r.batchable(filePath) && a.codeModelFor(filePath) == a.codeModelFor(first) &&
+		a.codeSystemPromptFor(filePath) == a.codeSystemPromptFor(first)
+}
+
+// generateBatch generates several files with one request and finishes each
+// one as generate does. Files the reply does not hold, or holds empty, are
+// generated on their own. It returns the error of each file.
+func (r *generationRun) generateBatch(filePaths []string) map[string]error {
+	a := r.agent
+	dones := make(map[string]func(), len(filePaths))
+	context := make(map[string]string)
+	for _, filePath := range filePaths {
+		dones[filePath] = a.startFile(filePath)
+		for contextPath, content := range r.contextFor(filePath) {
+			context[contextPath] = content
+		}
+	}
+
+	contents, err := a.GenerateBatch(r.spec, filePaths, context)
+	if err != nil {
+		a.Log.Warnf("Batch of %s failed (%v), generating the files one by one", batchNames(filePaths), err)
+	}
+
+	errs := make(map[string]error, len(filePaths))
+	for _, filePath := range filePaths {
+		content, ok := contents[filePath]
+		if !ok || strings.TrimSpace(content) == "" {
+			if err == nil {
+				a.Log.Warnf("The batch reply has no content for %s, generating it on its own", filePath)
+			}
+			errs[filePath] = r.generateStarted(filePath, dones[filePath])
+			continue
+		}
+		errs[filePath] = r.finish(filePath, content, r.sourceGenerator(filePath), dones[filePath])
+	}
+	return errs
+}
+
+// GenerateBatch generates the given files of the spec with a single
+// request, for projects with many small files. It returns the content of
+// each file found in the reply, which may miss some of them.
+func (a *DevAgent) GenerateBatch(spec *ProjectSpec, filePaths []string, context map[string]string) (map[string]string, error) {
+	var files strings.Builder
+	for _, filePath := range filePaths {
+		fmt.Fprintf(&files, "- %s: %s\n", filePath, spec.Files[filePath])
+	}
+
+	render := func(context map[string]string) (string, error) {
+		batchPrompt := fmt.Sprintf(`Generate the complete code for each of the following files in the %s project.
+Project Description: %s
+
+Files:
+%s
+Requirements:
+- Use %s framework
+- Follow best practices
+- Include necessary imports
+- Add helpful comments
+- Make sure the code is complete and functional
+- Ensure compatibility with other project files
+%s%s%s%s
+Write every file in this format, one after the other, with nothing in between:
+%s<file path>>>>
+<complete file content>
+%s
+
+Generate only the files, no explanations.`, spec.Name, spec.Description, files.String(), spec.Framework,
+			a.codeProfileInstruction(), a.importInstruction(spec), a.snippetInstruction(spec), describeContext(context), batchFileStart, batchFileEnd)
+		return a.wrapCodePrompt(batchPrompt), nil
+	}
+	prompt, err := render(context)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := a.chat(chatRequest{
+		Label:       strings.Join(filePaths, ", "),
+		Model:       a.codeModelFor(filePaths[0]),
+		System:      a.codeSystemPromptFor(filePaths[0]),
+		Prompt:      prompt,
+		Temperature: 0.2,
+		Prune:       pruneContext(context, render),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s: %v", strings.Join(filePaths, ", "), err)
+	}
+
+	contents := splitBatchReply(content, filePaths)
+	for filePath, fileContent := range contents {
+		contents[filePath] = a.spliceSnippets(spec, filePath, stripCodeFences(filePath, fileContent))
+	}
+	return contents, nil
+}
+
+// splitBatchReply splits a batched reply into the content of each file.
+// Files not requested are ignored, and a file left open at the end, as in
+// a truncated reply, is dropped rather than kept incomplete.
+func splitBatchReply(content string, filePaths []string) map[string]string {
+	requested := make(map[string]bool, len(filePaths))
+	for _, filePath := range filePaths {
+		requested[filePath] = true
+	}
+
+	contents := make(map[string]string)
+	current := ""
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, batchFileStart) && strings.HasSuffix(trimmed, ">>>"):
+			current = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(trimmed, batchFileStart), ">>>"))
+			lines = nil
+		case trimmed == batchFileEnd:
+			if requested[current] {
+				contents[current] = strings.Join(lines, "\n")
+			}
+			current = ""
+		case current != "":
+			lines = append(lines, line)
+		}
+	}
+	return contents
+}
+
+// batchNames lists the files of a batch for log messages.
+func batchNames(filePaths []string) string {
+	sorted := append([]string(nil), filePaths...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
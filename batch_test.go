@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestBatchable(t *testing.T) {
+	customTmpl := defaultSpecTmpl
+	tests := []struct {
+		name     string
+		agent    *DevAgent
+		filePath string
+		want     bool
+	}{
+		{"small file", &DevAgent{BatchFiles: 4, CodeTemplate: defaultCodeTmpl}, "main.go", true},
+		{"batching off", &DevAgent{BatchFiles: 1, CodeTemplate: defaultCodeTmpl}, "main.go", false},
+		{"custom template", &DevAgent{BatchFiles: 4, CodeTemplate: customTmpl}, "main.go", false},
+		{"themed ui file", &DevAgent{BatchFiles: 4, CodeTemplate: defaultCodeTmpl, Theme: "dark"}, "index.html", false},
+		{"themed non-ui file", &DevAgent{BatchFiles: 4, CodeTemplate: defaultCodeTmpl, Theme: "dark"}, "main.go", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := validSpec()
+			spec.Files["index.html"] = "Landing page"
+			r := &generationRun{agent: tt.agent, spec: spec}
+			if got := r.batchable(tt.filePath); got != tt.want {
+				t.Errorf("batchable(%s) = %v, want %v", tt.filePath, got, tt.want)
+			}
+		})
+	}
+}
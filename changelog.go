@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// changelogName is the changelog at the root of the repository.
+const changelogName = "CHANGELOG.md"
+
+// changelogTitle starts a changelog created from scratch.
+const changelogTitle = "# Changelog\n"
+
+// changedFiles returns the files below dir that are new or modified in the
+// working tree of the git repository at repoDir, relative to repoDir.
+func (a *DevAgent) changedFiles(repoDir, dir string) (added, modified []string, err error) {
+	// git runs in repoDir, so a relative dir would be resolved against it
+	if dir, err = filepath.Abs(dir); err != nil {
+		return nil, nil, err
+	}
+	untracked, err := a.git(repoDir, "ls-files", "-z", "--others", "--exclude-standard", "--", dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	changed, err := a.git(repoDir, "diff", "-z", "--name-only", "--", dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nulSeparated(untracked), nulSeparated(changed), nil
+}
+
+// nulSeparated splits the output of a git command run with -z, which
+// leaves paths unquoted, into its entries.
+func nulSeparated(output string) []string {
+	var paths []string
+	for _, path := range strings.Split(output, "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// UpdateChangelog adds an entry for the files a run added to or changed in
+// the git repository at repoDir to its CHANGELOG.md, creating it if needed.
+// The new entry goes above the previous ones and is written by the model
+// from the files themselves. It returns the changelog path relative to
+// repoDir, or "" when nothing changed.
+func (a *DevAgent) UpdateChangelog(spec *ProjectSpec, repoDir, projectDir string) (string, error) {
+	added, modified, err := a.changedFiles(repoDir, projectDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to list the changed files: %v", err)
+	}
+	if len(added) == 0 && len(modified) == 0 {
+		a.Log.Infof("⏭️  Skipping %s: no files were added or changed", changelogName)
+		return "", nil
+	}
+
+	context := make(map[string]string, len(added)+len(modified))
+	for _, filePath := range append(append([]string(nil), added...), modified...) {
+		if filePath == changelogName {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(repoDir, filePath))
+		if err != nil {
+			return "", describeFSError("read", filePath, err)
+		}
+		context[filePath] = string(content)
+	}
+
+	changelogPath := filepath.Join(repoDir, changelogName)
+	existing, err := os.ReadFile(changelogPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", describeFSError("read", changelogName, err)
+	}
+
+	done := a.startFile(changelogName)
+
+	render := func(context map[string]string) (string, error) {
+		return fmt.Sprintf(`Write a changelog entry for the following change to a codebase: %s
+Description: %s
+
+Added files: %s
+Modified files: %s
+%s
+Requirements:
+- Use "### Added" and "### Changed" sections, leaving out a section with no items
+- Write one bullet per user-visible feature or change, describing what it does rather than listing files
+- Keep each bullet to one line
+%s
+Generate only the sections, without a heading for the entry, no explanations.`, spec.Name, spec.Description,
+			listOrNone(added), listOrNone(modified), describeContext(context), latestChangelogEntry(string(existing))), nil
+	}
+	prompt, err := render(context)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := a.chat(chatRequest{
+		Label:       changelogName,
+		Model:       a.ReadmeModel,
+		System:      "You are a technical writer who keeps concise, accurate changelogs.",
+		Prompt:      prompt,
+		Temperature: 0.2,
+		Prune:       pruneContext(context, render),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate %s: %v", changelogName, err)
+	}
+
+	entry := fmt.Sprintf("## %s - %s\n\n%s\n", time.Now().Format("2006-01-02"), spec.Name, strings.TrimSpace(stripCodeFences(changelogName, content)))
+	if err := writeProjectFile(repoDir, changelogName, insertChangelogEntry(string(existing), entry)); err != nil {
+		return "", err
+	}
+	done()
+	a.Log.Infof("📰 Added an entry for %s to %s", spec.Name, changelogName)
+	return changelogName, nil
+}
+
+// listOrNone joins paths for a prompt, or returns "none".
+func listOrNone(paths []string) string {
+	if len(paths) == 0 {
+		return "none"
+	}
+	return strings.Join(paths, ", ")
+}
+
+// latestChangelogEntry asks the entry to follow the style of the newest
+// entry of an existing changelog, if it has one.
+func latestChangelogEntry(changelog string) string {
+	changelog = "\n" + changelog
+	start := strings.Index(changelog, "\n## ")
+	if start < 0 {
+		return ""
+	}
+	entry := changelog[start+1:]
+	if end := strings.Index(entry, "\n## "); end >= 0 {
+		entry = entry[:end]
+	}
+	return fmt.Sprintf("- Match the style of the previous entry:\n```\n%s\n```\n", strings.TrimSpace(entry))
+}
+
+// insertChangelogEntry puts entry above the first entry of changelog,
+// after its title and introduction, or starts a new changelog.
+func insertChangelogEntry(changelog, entry string) string {
+	if strings.TrimSpace(changelog) == "" {
+		return changelogTitle + "\n" + entry
+	}
+	if strings.HasPrefix(changelog, "## ") {
+		return entry + "\n" + changelog
+	}
+	if i := strings.Index(changelog, "\n## "); i >= 0 {
+		return changelog[:i+1] + entry + "\n" + changelog[i+1:]
+	}
+	return strings.TrimRight(changelog, "\n") + "\n\n" + entry
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// errEmptyResponse is returned when the model replies without any choices.
+var errEmptyResponse = errors.New("model returned no choices")
+
+// jsonModeModels lists the model prefixes that accept the json_object
+// response format; jsonModeExceptions are matched first and do not.
+var (
+	jsonModeModels     = []string{"gpt-3.5-turbo", "gpt-4-turbo", "gpt-4-1106", "gpt-4-0125", "gpt-4o", "gpt-4.1", "o1", "o3", "o4-mini"}
+	jsonModeExceptions = []string{"gpt-3.5-turbo-0613", "gpt-3.5-turbo-16k", "o1-mini", "o1-preview"}
+)
+
+// supportsJSONMode reports whether the model can be forced to reply with
+// a JSON object.
+func supportsJSONMode(model string) bool {
+	for _, prefix := range jsonModeExceptions {
+		if strings.HasPrefix(model, prefix) {
+			return false
+		}
+	}
+	for _, prefix := range jsonModeModels {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// chatRequest describes a single system/user prompt exchange.
+type chatRequest struct {
+	Label       string // what is being generated, e.g. a file path
+	Step        string // which part of Label this request produces, if any
+	Model       string
+	System      string
+	Prompt      string
+	Temperature float32
+	// JSON asks for a JSON object reply on models that support it
+	JSON bool
+	// Prune, if set, returns the prompt with less context when it does
+	// not fit the model, or false when there is nothing left to drop
+	Prune func() (string, bool)
+}
+
+// chatReply is the content of a reply and why the model stopped.
+type chatReply struct {
+	Content      string
+	FinishReason openai.FinishReason
+}
+
+// truncated reports whether the model stopped at its output token limit.
+func (r chatReply) truncated() bool {
+	return r.FinishReason == openai.FinishReasonLength
+}
+
+// name describes the request in log messages.
+func (r chatRequest) name() string {
+	if r.Step == "" {
+		return r.Label
+	}
+	return r.Label + " " + r.Step
+}
+
+// chat sends the request to the model and returns the reply content.
+func (a *DevAgent) chat(req chatRequest) (string, error) {
+	reply, err := a.chatReply(req)
+	return reply.Content, err
+}
+
+// chatReply sends the request to the model and returns its reply. A
+// prompt that exceeds the model's context is retried with the model's
+// entry in ContextUpgrades, then with pruned context if the request
+// allows it.
+func (a *DevAgent) chatReply(req chatRequest) (chatReply, error) {
+	tried := make(map[string]bool)
+	for {
+		reply, err := a.chatProviders(req)
+		if err == nil || !isContextLengthError(err) {
+			return reply, err
+		}
+		tried[req.Model] = true
+
+		if upgrade, ok := a.contextUpgrade(req.Model); ok && !tried[upgrade] {
+			a.Log.Warnf("Prompt for %s exceeds the context of %s, retrying with %s", req.name(), req.Model, upgrade)
+			req.Model = upgrade
+			continue
+		}
+		if req.Prune != nil {
+			if prompt, ok := req.Prune(); ok {
+				a.Log.Warnf("Prompt for %s exceeds the context of %s, retrying with less context", req.name(), req.Model)
+				req.Prompt = prompt
+				continue
+			}
+		}
+		return chatReply{}, err
+	}
+}
+
+// chatProviders sends the request to each provider in turn. The prompt
+// size is estimated first so oversized requests are flagged before they
+// are paid for. Transient failures are retried, and once the retries with
+// one provider are exhausted the next provider is tried.
+func (a *DevAgent) chatProviders(req chatRequest) (chatReply, error) {
+	tokens := estimateChatTokens(req.Model, req.System, req.Prompt)
+	limit := contextLimit(req.Model)
+	a.Log.Debugf("Prompt for %s: ~%d tokens (%.0f%% of %s's %d token context)", req.name(), tokens, float64(tokens)*100/float64(limit), req.Model, limit)
+	if tokens > limit {
+		a.Log.Warnf("Prompt for %s is ~%d tokens, which exceeds the %d token context of %s", req.name(), tokens, limit, req.Model)
+	} else if float64(tokens) > float64(limit)*contextWarnRatio {
+		a.Log.Warnf("Prompt for %s is ~%d tokens, close to the %d token context of %s", req.name(), tokens, limit, req.Model)
+	}
+
+	var lastErr error
+	for i, provider := range a.Providers {
+		if i > 0 {
+			a.Log.Warnf("Falling back to provider %s for %s", provider.Name(), req.name())
+		}
+		reply, err := a.chatWith(provider, req, tokens)
+		if err == nil {
+			a.recordProvider(req.Label, provider.Name())
+			if i > 0 {
+				a.Log.Infof("↪️  %s was generated by %s", req.name(), provider.Name())
+			}
+			return reply, nil
+		}
+		if !a.isRetryable(err) {
+			return chatReply{}, err
+		}
+		lastErr = err
+	}
+	return chatReply{}, lastErr
+}
+
+// chatWith sends the request to one provider, retrying transient failures
+// and recording the usage of every attempt.
+func (a *DevAgent) chatWith(provider Provider, req chatRequest, tokens int) (chatReply, error) {
+	jsonMode := req.JSON && supportsJSONMode(req.Model)
+	for attempt := 0; ; attempt++ {
+		var responseFormat *openai.ChatCompletionResponseFormat
+		if jsonMode {
+			responseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+		}
+		request := openai.ChatCompletionRequest{
+			Model: req.Model,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: a.styledSystemPrompt(req.Model, req.System),
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: req.Prompt,
+				},
+			},
+			Temperature:    req.Temperature,
+			ResponseFormat: responseFormat,
+		}
+		a.applyChatParams(&request)
+		resp, err := provider.CreateChatCompletion(a.ctx, request)
+		if err == nil && len(resp.Choices) == 0 {
+			err = errEmptyResponse
+		}
+		if err == nil {
+			// The reply names the model that actually ran, which differs
+			// from the requested one on providers that force a model
+			model := resp.Model
+			if model == "" {
+				model = req.Model
+			}
+			a.Usage.Record(req.Label, model, resp.Usage)
+			return chatReply{Content: resp.Choices[0].Message.Content, FinishReason: resp.Choices[0].FinishReason}, nil
+		}
+
+		if isBillable(err) {
+			a.Usage.RecordFailure(req.Label, req.Model, resp.Usage, tokens)
+		} else {
+			a.Usage.RecordFailure(req.Label, req.Model, resp.Usage, 0)
+		}
+
+		// Backends that serve a different model than requested may reject
+		// JSON mode; the reply is still parsed without it
+		if jsonMode && httpStatus(err) == http.StatusBadRequest && !isContextLengthError(err) {
+			a.Log.Debugf("%s rejected JSON mode for %s (%v), retrying without it", provider.Name(), req.name(), err)
+			jsonMode = false
+			attempt--
+			continue
+		}
+
+		if attempt >= a.MaxRetries || !a.isRetryable(err) {
+			return chatReply{}, err
+		}
+		delay := retryDelay(attempt + 1)
+		a.Log.Warnf("Request for %s to %s failed (%v), retrying in %s (%d/%d)", req.name(), provider.Name(), err, delay, attempt+1, a.MaxRetries)
+		if err := sleepContext(a.ctx, delay); err != nil {
+			return chatReply{}, err
+		}
+	}
+}
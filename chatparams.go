@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Values accepted by -reasoning-effort, -verbosity and -service-tier.
+var (
+	reasoningEfforts = []string{"minimal", "low", "medium", "high"}
+	verbosityLevels  = []string{"low", "medium", "high"}
+	serviceTiers     = []string{"auto", "default", "flex", "priority"}
+)
+
+// reasoningModels lists the model prefixes that accept reasoning_effort;
+// reasoningExceptions are matched first and do not. Only the gpt-5 family
+// accepts the minimal effort and verbosity.
+var (
+	reasoningModels     = []string{"o1", "o3", "o4-mini", "gpt-5"}
+	reasoningExceptions = []string{"o1-mini", "o1-preview"}
+	verbosityModels     = []string{"gpt-5"}
+	serviceTierModels   = []string{"gpt-", "o1", "o3", "o4-mini"}
+)
+
+// hasModelPrefix reports whether model starts with one of prefixes.
+func hasModelPrefix(model string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// supportsReasoningEffort reports whether the model accepts the given
+// reasoning effort.
+func supportsReasoningEffort(model, effort string) bool {
+	if hasModelPrefix(model, reasoningExceptions) || !hasModelPrefix(model, reasoningModels) {
+		return false
+	}
+	return effort != "minimal" || hasModelPrefix(model, verbosityModels)
+}
+
+// applyChatParams sets the optional chat parameters on a request to model.
+// Parameters the model does not support are left out, so one setting can
+// be used with a mix of models.
+func (a *DevAgent) applyChatParams(request *openai.ChatCompletionRequest) {
+	model := request.Model
+	if a.ReasoningEffort != "" {
+		if supportsReasoningEffort(model, a.ReasoningEffort) {
+			request.ReasoningEffort = a.ReasoningEffort
+		} else {
+			a.Log.Debugf("Not sending reasoning effort %s: %s does not support it", a.ReasoningEffort, model)
+		}
+	}
+	if a.Verbosity != "" {
+		if hasModelPrefix(model, verbosityModels) {
+			request.Verbosity = a.Verbosity
+		} else {
+			a.Log.Debugf("Not sending verbosity %s: %s does not support it", a.Verbosity, model)
+		}
+	}
+	if a.ServiceTier != "" {
+		if hasModelPrefix(model, serviceTierModels) {
+			request.ServiceTier = openai.ServiceTier(a.ServiceTier)
+		} else {
+			a.Log.Debugf("Not sending service tier %s: %s does not support it", a.ServiceTier, model)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fileSection is one part of a large file's outline.
+type fileSection struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// isLargeFile reports whether the spec marks the file as too large to be
+// generated in a single response.
+func isLargeFile(spec *ProjectSpec, filePath string) bool {
+	for _, large := range spec.LargeFiles {
+		if large == filePath {
+			return true
+		}
+	}
+	return false
+}
+
+// generateLargeFile generates a file section by section: it first asks for
+// an outline of the file, then generates each section with the outline and
+// the sections so far as context, and concatenates the results. Each
+// section is rendered with the code template, whose file purpose names the
+// section to write.
+func (a *DevAgent) generateLargeFile(spec *ProjectSpec, filePath string, context map[string]string) (string, error) {
+	description := spec.Files[filePath]
+
+	renderOutline := func(context map[string]string) (string, error) {
+		outlinePrompt := fmt.Sprintf(`Plan the file %s in the %s project, which is too large to write in one response.
+Project Description: %s
+File Purpose: %s
+Framework: %s
+%s%s
+Split the file into consecutive sections (imports and setup first) that can each be written on their own.
+Respond only with valid JSON in the following structure:
+[
+  {"title": "<section title>", "description": "<what the section contains>"},
+  ...
+]`, filePath, spec.Name, spec.Description, description, spec.Framework, a.codeProfileInstruction(), describeContext(context))
+		return a.wrapCodePrompt(outlinePrompt), nil
+	}
+	prompt, err := renderOutline(context)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := a.chat(chatRequest{
+		Label:       filePath,
+		Step:        "outline",
+		Model:       a.CodeModel,
+		System:      "You are an expert programmer planning the structure of a source file.",
+		Prompt:      prompt,
+		Temperature: 0.2,
+		Prune:       pruneContext(context, renderOutline),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate outline for %s: %v", filePath, err)
+	}
+
+	var outline []fileSection
+	err = a.parseModelJSON(content, &outline)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse outline for %s: %v", filePath, err)
+	}
+	if len(outline) == 0 {
+		return "", fmt.Errorf("outline for %s has no sections", filePath)
+	}
+
+	var outlineText strings.Builder
+	for i, section := range outline {
+		outlineText.WriteString(fmt.Sprintf("%d. %s: %s\n", i+1, section.Title, section.Description))
+	}
+
+	var sections []string
+	for i, section := range outline {
+		a.Log.Infof("   ⤷ Section %d/%d: %s", i+1, len(outline), section.Title)
+
+		var previous string
+		if len(sections) > 0 {
+			previous = fmt.Sprintf("\nSections written so far:\n```\n%s\n```", strings.Join(sections, "\n\n"))
+		}
+		sectionDescription := fmt.Sprintf(`%s
+
+The file is too large for one response and is written section by section.
+File outline:
+%s
+Write only section %d of %d (%s): %s
+Do not repeat code from earlier sections, and make sure the section fits with the outline and the sections before it.%s`,
+			description, outlineText.String(), i+1, len(outline), section.Title, section.Description, previous)
+
+		render := func(context map[string]string) (string, error) {
+			sectionPrompt, err := renderPrompt(a.CodeTemplate, a.promptDataFor(spec, filePath, sectionDescription, context))
+			if err != nil {
+				return "", err
+			}
+			return a.wrapCodePrompt(sectionPrompt), nil
+		}
+		prompt, err := render(context)
+		if err != nil {
+			return "", err
+		}
+
+		content, err := a.chat(chatRequest{
+			Label:       filePath,
+			Step:        fmt.Sprintf("section %d", i+1),
+			Model:       a.codeModelFor(filePath),
+			System:      a.codeSystemPromptFor(filePath),
+			Prompt:      prompt,
+			Temperature: 0.2,
+			Prune:       pruneContext(context, render),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to generate section %d of %s: %v", i+1, filePath, err)
+		}
+		sections = append(sections, stripCodeFences(filePath, content))
+	}
+
+	return a.spliceSnippets(spec, filePath, strings.Join(sections, "\n\n")), nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ciWorkflows maps the CI providers accepted by -ci to the workflow file
+// each one reads.
+var ciWorkflows = map[string]string{
+	"github": ".github/workflows/ci.yml",
+	"gitlab": ".gitlab-ci.yml",
+}
+
+// ciProviderNames returns the supported CI providers in alphabetical order.
+func ciProviderNames() []string {
+	names := make([]string, 0, len(ciWorkflows))
+	for name := range ciWorkflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ciSystemNames are the product names used in the workflow prompt.
+var ciSystemNames = map[string]string{
+	"github": "GitHub Actions",
+	"gitlab": "GitLab CI/CD",
+}
+
+// GenerateCIWorkflow asks the model for a CI workflow of the given
+// provider that installs, builds, lints and tests the generated project,
+// and writes it where the provider expects it. It returns an empty path if
+// the project already has that workflow.
+func (a *DevAgent) GenerateCIWorkflow(spec *ProjectSpec, projectDir, provider string, generatedFiles map[string]string) (string, error) {
+	workflowPath, ok := ciWorkflows[provider]
+	if !ok {
+		return "", fmt.Errorf("unknown CI provider %q (valid providers: %s)", provider, strings.Join(ciProviderNames(), ", "))
+	}
+	if _, ok := generatedFiles[workflowPath]; ok {
+		a.Log.Infof("⏭️  Skipping CI workflow: the project already has %s", workflowPath)
+		return "", nil
+	}
+	if a.keepExisting(projectDir, workflowPath) {
+		return "", nil
+	}
+
+	// The file list, dependency manifests and Makefile describe how the
+	// project is built without sending every source file again
+	var filePaths []string
+	var buildFiles strings.Builder
+	for filePath, content := range generatedFiles {
+		filePaths = append(filePaths, filePath)
+		isBuildFile := filePath == makefileName
+		for _, manifestName := range dependencyManifests {
+			if filepath.Base(filePath) == manifestName {
+				isBuildFile = true
+			}
+		}
+		if isBuildFile {
+			buildFiles.WriteString(fmt.Sprintf("\n%s:\n```\n%s\n```\n", filePath, content))
+		}
+	}
+	sort.Strings(filePaths)
+
+	done := a.startFile(workflowPath)
+
+	ciPrompt := fmt.Sprintf(`Generate a %s workflow (%s) for the %s project.
+Project Description: %s
+Framework: %s
+
+Project files:
+%s
+%s
+Requirements:
+- Run on pushes and pull requests to the default branch
+- Set up the language toolchain the framework needs and cache its dependencies
+- Install dependencies, then build, lint and test the project using the standard commands of the framework
+- Use the Makefile targets if the project has a Makefile
+- Only run steps that apply to the files listed above
+Generate only the file content, no explanations.`, ciSystemNames[provider], workflowPath, spec.Name, spec.Description, spec.Framework, strings.Join(filePaths, "\n"), buildFiles.String())
+
+	content, err := a.chat(chatRequest{
+		Label:       workflowPath,
+		Model:       a.CodeModel,
+		System:      "You are an expert in continuous integration. Generate only the workflow YAML, no explanations or markdown.",
+		Prompt:      a.wrapCodePrompt(ciPrompt),
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate %s: %v", workflowPath, err)
+	}
+
+	content = a.finalizeContent(workflowPath, stripCodeFences(workflowPath, content))
+	generatedFiles[workflowPath] = content
+
+	err = writeProjectFile(projectDir, workflowPath, content)
+	if err != nil {
+		return "", err
+	}
+	done()
+	return workflowPath, nil
+}
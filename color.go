@@ -0,0 +1,99 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// Values of -color.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// colorModes lists the accepted -color values.
+var colorModes = []string{colorAuto, colorAlways, colorNever}
+
+// ANSI escape sequences used to color status lines.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiDim    = "\x1b[2m"
+)
+
+// lineColors maps the prefixes of status lines to their color. Errors
+// printed outside the logger start with "Error".
+var lineColors = []struct {
+	prefix string
+	color  string
+}{
+	{"❌", ansiRed},
+	{"Error", ansiRed},
+	{"⚠️", ansiYellow},
+	{"🔍", ansiDim},
+	{"✅", ansiGreen},
+	{"✨", ansiGreen},
+}
+
+// useColor reports whether output to f is colored in the given -color
+// mode. In auto mode color is used only when f is a terminal, TERM is not
+// dumb and NO_COLOR is not set (see https://no-color.org).
+func useColor(f *os.File, mode string) bool {
+	switch mode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(f)
+}
+
+// consoleWriter wraps f for console output, replacing emoji when plain is
+// set and coloring lines as the -color mode asks. Lines are colored by
+// their emoji prefix, so coloring wraps the emoji replacement.
+func consoleWriter(f *os.File, plain bool, mode string) io.Writer {
+	var w io.Writer = f
+	if plain {
+		w = plainWriter{w}
+	}
+	if useColor(f, mode) {
+		w = colorWriter{w}
+	}
+	return w
+}
+
+// colorLine colors a line by its status prefix, leaving other lines as
+// they are.
+func colorLine(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	for _, c := range lineColors {
+		if strings.HasPrefix(trimmed, c.prefix) {
+			return c.color + line + ansiReset
+		}
+	}
+	return line
+}
+
+// colorWriter writes to w with errors, warnings, debug and success lines
+// colored.
+type colorWriter struct {
+	w io.Writer
+}
+
+func (c colorWriter) Write(data []byte) (int, error) {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		lines[i] = colorLine(line)
+	}
+	if _, err := io.WriteString(c.w, strings.Join(lines, "\n")); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
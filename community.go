@@ -0,0 +1,175 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// communityTemplates are the community health files written by
+// CommunityFiles, keyed by their path in the project. They are rendered
+// with the project spec, so they cost no tokens.
+var communityTemplates = map[string]string{
+	"CONTRIBUTING.md": `# Contributing to {{.Name}}
+
+Thank you for your interest in contributing to {{.Name}}!
+
+## Reporting issues
+
+Before opening an issue, search the existing ones to see whether it was
+already reported. Include the steps to reproduce the problem, what you
+expected to happen and what happened instead.
+
+## Submitting changes
+
+1. Fork the repository and create a branch from the default branch.
+2. Make your change, keeping it focused on a single concern.
+3. Add or update tests for the behavior you changed.
+4. Make sure the project builds and all tests pass.
+5. Open a pull request describing the change and why it is needed.
+
+## Code of conduct
+
+This project follows a [code of conduct](CODE_OF_CONDUCT.md). By
+participating you agree to uphold it.
+`,
+	"CODE_OF_CONDUCT.md": `# Code of Conduct
+
+## Our pledge
+
+We as members, contributors and maintainers of {{.Name}} pledge to make
+participation in our community a harassment-free experience for everyone,
+regardless of age, body size, disability, ethnicity, sex characteristics,
+gender identity and expression, level of experience, education,
+socio-economic status, nationality, personal appearance, race, religion or
+sexual identity and orientation.
+
+## Our standards
+
+Examples of behavior that contributes to a positive environment:
+
+- Being respectful of differing opinions, viewpoints and experiences
+- Giving and gracefully accepting constructive feedback
+- Focusing on what is best for the community
+
+Examples of unacceptable behavior:
+
+- Harassment, insults or derogatory comments
+- Publishing others' private information without their explicit permission
+- Other conduct which could reasonably be considered inappropriate
+
+## Enforcement
+
+Instances of unacceptable behavior may be reported to the project
+maintainers. All complaints will be reviewed and investigated promptly and
+fairly, and maintainers will respect the privacy of the reporter.
+
+## Attribution
+
+This code of conduct is adapted from the [Contributor Covenant](https://www.contributor-covenant.org),
+version 2.1.
+`,
+	"SECURITY.md": `# Security Policy
+
+## Reporting a vulnerability
+
+Please do not report security vulnerabilities in {{.Name}} through public
+issues. Instead, contact the maintainers privately with a description of
+the vulnerability, the steps to reproduce it and its possible impact.
+
+You will receive a response as soon as possible. Once the issue is
+confirmed, a fix will be released and the vulnerability disclosed.
+`,
+	".github/ISSUE_TEMPLATE/bug_report.md": `---
+name: Bug report
+about: Report a problem with {{.Name}}
+labels: bug
+---
+
+**Describe the bug**
+A clear description of what the bug is.
+
+**To reproduce**
+Steps to reproduce the behavior.
+
+**Expected behavior**
+What you expected to happen.
+
+**Environment**
+Operating system, versions and any other relevant details.
+`,
+	".github/ISSUE_TEMPLATE/feature_request.md": `---
+name: Feature request
+about: Suggest an idea for {{.Name}}
+labels: enhancement
+---
+
+**Problem**
+The problem this feature would solve.
+
+**Proposed solution**
+How you would like it to work.
+
+**Alternatives**
+Other solutions you considered.
+`,
+	".github/pull_request_template.md": `## Summary
+
+What this change does and why.
+
+## Testing
+
+How the change was tested.
+`,
+}
+
+// communityFiles renders the community health files for spec, keyed by
+// path.
+func communityFiles(spec *ProjectSpec) (map[string]string, error) {
+	files := make(map[string]string, len(communityTemplates))
+	for filePath, text := range communityTemplates {
+		tmpl, err := template.New(filePath).Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, spec); err != nil {
+			return nil, err
+		}
+		files[filePath] = b.String()
+	}
+	return files, nil
+}
+
+// GenerateCommunityFiles writes the community health files, such as
+// CONTRIBUTING.md and CODE_OF_CONDUCT.md, from templates. Files the spec
+// already plans are left alone. It returns the written paths.
+func (a *DevAgent) GenerateCommunityFiles(spec *ProjectSpec, projectDir string, generatedFiles map[string]string) ([]string, error) {
+	files, err := communityFiles(spec)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(files))
+	for filePath := range files {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+
+	var written []string
+	for _, filePath := range paths {
+		if _, ok := generatedFiles[filePath]; ok {
+			continue
+		}
+		if a.keepExisting(projectDir, filePath) {
+			continue
+		}
+		done := a.startFile(filePath)
+		generatedFiles[filePath] = files[filePath]
+		if err := writeProjectFile(projectDir, filePath, files[filePath]); err != nil {
+			return written, err
+		}
+		written = append(written, filePath)
+		done()
+	}
+	return written, nil
+}
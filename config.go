@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Config holds settings loaded from the file given with -config.
+type Config struct {
+	// FrameworkExtensions adds or replaces entries of the framework to
+	// file extension mapping used to validate specs
+	FrameworkExtensions map[string][]string `json:"framework_extensions"`
+	// Profiles adds or replaces the prompt profiles selectable with
+	// -profile
+	Profiles map[string]Profile `json:"profiles"`
+	// ContextUpgrades adds or replaces the larger-context models requests
+	// are retried with when a prompt does not fit; an empty model turns
+	// the upgrade off
+	ContextUpgrades map[string]string `json:"context_upgrades"`
+	// ModelStyles adds or replaces the prompt styles of model families,
+	// keyed by model name prefix; an empty style turns it off
+	ModelStyles map[string]ModelStyle `json:"model_styles"`
+	// ConfirmDefault is the answer, y or n, to "Proceed with generation?"
+	// when only Enter is pressed; n unless set
+	ConfirmDefault string `json:"confirm_default"`
+}
+
+// loadConfig reads a JSON config file.
+func loadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, describeFSError("read config file", path, err)
+	}
+
+	var config Config
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	switch config.ConfirmDefault {
+	case "", "y", "n":
+	default:
+		return nil, fmt.Errorf("invalid confirm_default %q in config file %s: use y or n", config.ConfirmDefault, path)
+	}
+	return &config, nil
+}
+
+// Apply applies the config to the agent.
+func (c *Config) Apply(a *DevAgent) {
+	for keyword, extensions := range c.FrameworkExtensions {
+		a.FrameworkExtensions[keyword] = extensions
+	}
+	for name, profile := range c.Profiles {
+		a.Profiles[name] = profile
+	}
+	for model, upgrade := range c.ContextUpgrades {
+		a.ContextUpgrades[model] = upgrade
+	}
+	for prefix, style := range c.ModelStyles {
+		a.ModelStyles[prefix] = style
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// redacted replaces credentials in the dumped configuration.
+const redacted = "REDACTED"
+
+// resolvedConfig is the configuration a run uses once flags, the config
+// file and defaults are combined, as printed by -config-dump.
+type resolvedConfig struct {
+	APIKey    string            `json:"api_key"`
+	Providers []providerConfig  `json:"providers"`
+	Models    map[string]string `json:"models"`
+
+	Temperature        float32 `json:"temperature"`
+	FixTemperatureStep float32 `json:"fix_temperature_step"`
+	MaxFixTemperature  float32 `json:"max_fix_temperature"`
+	ReasoningEffort    string  `json:"reasoning_effort,omitempty"`
+	Verbosity          string  `json:"verbosity,omitempty"`
+	ServiceTier        string  `json:"service_tier,omitempty"`
+
+	Concurrency          int      `json:"concurrency"`
+	MaxConcurrencyPerDir int      `json:"max_concurrency_per_dir"`
+	BatchFiles           int      `json:"batch_files"`
+	MaxRetries           int      `json:"max_retries"`
+	RetryStatuses        []string `json:"retry_statuses"`
+	FixAttempts          int      `json:"fix_attempts"`
+	MaxRuntime           string   `json:"max_runtime"`
+
+	ContextTopK   int `json:"context_top_k"`
+	ContextWindow int `json:"context_window"`
+
+	OutputDir string   `json:"output_dir"`
+	OnExists  string   `json:"on_exists"`
+	Profile   string   `json:"profile,omitempty"`
+	Profiles  []string `json:"profiles"`
+	Extras    []string `json:"extras"`
+
+	ContextUpgrades map[string]string     `json:"context_upgrades"`
+	ModelStyles     map[string]ModelStyle `json:"model_styles"`
+	ConfirmDefault  string                `json:"confirm_default"`
+}
+
+// providerConfig is one provider of the fallback chain, with the model it
+// forces, if any.
+type providerConfig struct {
+	Name  string `json:"name"`
+	Model string `json:"model,omitempty"`
+}
+
+// resolvedConfig collects the agent's configuration. The API key is only
+// shown as set or not.
+func (a *DevAgent) resolvedConfig(apiKey, confirmDefault string) resolvedConfig {
+	config := resolvedConfig{
+		Providers: []providerConfig{},
+		Models: map[string]string{
+			"spec":    a.SpecModel,
+			"code":    a.CodeModel,
+			"readme":  a.ReadmeModel,
+			"enhance": a.EnhanceModel,
+			"summary": a.SummaryModel,
+		},
+		Temperature:          baseTemperature,
+		FixTemperatureStep:   a.FixTemperatureStep,
+		MaxFixTemperature:    maxFixTemperature,
+		ReasoningEffort:      a.ReasoningEffort,
+		Verbosity:            a.Verbosity,
+		ServiceTier:          a.ServiceTier,
+		Concurrency:          a.Concurrency,
+		MaxConcurrencyPerDir: a.MaxConcurrencyPerDir,
+		BatchFiles:           a.BatchFiles,
+		MaxRetries:           a.MaxRetries,
+		RetryStatuses:        formatRetryStatuses(a.RetryStatuses),
+		FixAttempts:          a.FixAttempts,
+		MaxRuntime:           a.MaxRuntime.String(),
+		ContextTopK:          a.ContextTopK,
+		ContextWindow:        a.ContextWindow,
+		OutputDir:            a.OutputDir,
+		OnExists:             a.OnExists,
+		Profile:              a.Profile,
+		Profiles:             a.profileNames(),
+		Extras:               a.enabledExtras(),
+		ContextUpgrades:      a.ContextUpgrades,
+		ModelStyles:          a.ModelStyles,
+		ConfirmDefault:       confirmDefault,
+	}
+	if apiKey != "" {
+		config.APIKey = redacted
+	}
+	for _, provider := range a.Providers {
+		entry := providerConfig{Name: provider.Name()}
+		if compatible, ok := provider.(*compatibleProvider); ok {
+			entry.Model = compatible.model
+		}
+		config.Providers = append(config.Providers, entry)
+	}
+	for language, model := range a.LanguageModels {
+		config.Models["code."+language] = model
+	}
+	return config
+}
+
+// enabledExtras lists the extras the agent generates, in the order of
+// extras.
+func (a *DevAgent) enabledExtras() []string {
+	enabled := map[string]bool{
+		"readme":          !a.NoReadme,
+		"tests":           a.WithTests,
+		"makefile":        a.Makefile,
+		"ci":              a.CI != "",
+		"env-example":     a.EnvExample,
+		"diagram":         a.Diagram,
+		"community-files": a.CommunityFiles,
+		"k8s":             a.K8s,
+	}
+	names := []string{}
+	for _, name := range extraNames() {
+		if enabled[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// dumpConfig writes the resolved configuration as indented JSON, to check
+// what a run would use, e.g. after combining flags with a config file.
+func (a *DevAgent) dumpConfig(w io.Writer, apiKey, confirmDefault string) error {
+	data, err := json.MarshalIndent(a.resolvedConfig(apiKey, confirmDefault), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %v", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// Answers of a file review
+const (
+	reviewWrite      = "write"
+	reviewRegenerate = "regenerate"
+	reviewSkip       = "skip"
+)
+
+// promptFileReview returns a ReviewFile function that shows each generated
+// file and asks on reader whether to write, regenerate or skip it.
+func promptFileReview(reader *bufio.Reader) func(filePath, content string) string {
+	return func(filePath, content string) string {
+		fmt.Fprintf(stdout, "\n📝 %s:\n", filePath)
+		for _, line := range strings.Split(content, "\n") {
+			fmt.Fprintln(stdout, "   │ "+line)
+		}
+		for {
+			fmt.Fprintf(stdout, "Write %s? (y = write, r = regenerate, s = skip): ", filePath)
+			answer, err := reader.ReadString('\n')
+			if err != nil {
+				// Without input the safe choice is to leave the file out
+				return reviewSkip
+			}
+			switch strings.TrimSpace(strings.ToLower(answer)) {
+			case "y", "yes", "w":
+				return reviewWrite
+			case "r":
+				return reviewRegenerate
+			case "s", "n", "no":
+				return reviewSkip
+			}
+		}
+	}
+}
+
+// approve passes generated content to ReviewFile until it is written or
+// skipped, regenerating it on request. It returns the content to write and
+// false if the file is skipped.
+func (r *generationRun) approve(filePath, content string, regenerate func() (string, error)) (string, bool, error) {
+	a := r.agent
+	if a.ReviewFile == nil {
+		return content, true, nil
+	}
+	for {
+		switch a.ReviewFile(filePath, content) {
+		case reviewWrite:
+			return content, true, nil
+		case reviewSkip:
+			a.Log.Infof("⏭️  Skipped %s", filePath)
+			return "", false, nil
+		}
+
+		a.Log.Infof("🔄 Regenerating %s...", filePath)
+		regenerated, err := regenerate()
+		if err != nil {
+			return "", false, err
+		}
+		content = a.finalizeContent(filePath, r.keepManualEdits(filePath, regenerated))
+	}
+}
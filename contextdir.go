@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxContextFileSize is the largest file, in bytes, loaded from -context-dir;
+// larger files are mostly generated or data and would crowd out the prompt.
+const maxContextFileSize = 64 * 1024
+
+// skippedContextDirs are directories of dependencies and build output that
+// do not show a codebase's conventions.
+var skippedContextDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "dist": true, "build": true, "target": true, "__pycache__": true, "venv": true,
+}
+
+// loadContextDir reads the text files below dir, keyed by their slash
+// separated path relative to dir. Hidden files and directories, dependency
+// and build directories, binary files and files larger than
+// maxContextFileSize are skipped.
+func loadContextDir(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(name, ".") || skippedContextDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(name, ".") || !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxContextFileSize {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		return nil, describeFSError("read context directory", dir, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no text files found in context directory %s", dir)
+	}
+	return files, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// defaultContextUpgrades maps models to a model with a larger context
+// window that a request is retried with when its prompt does not fit.
+var defaultContextUpgrades = map[string]string{
+	"gpt-3.5-turbo": "gpt-4o-mini",
+	"gpt-4":         "gpt-4o",
+	"gpt-4-32k":     "gpt-4o",
+	"gpt-4-turbo":   "gpt-4.1",
+	"gpt-4o":        "gpt-4.1",
+	"gpt-4o-mini":   "gpt-4.1-mini",
+}
+
+// isContextLengthError reports whether the API rejected a request because
+// the prompt exceeds the model's context window.
+func isContextLengthError(err error) bool {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && fmt.Sprint(apiErr.Code) == "context_length_exceeded" {
+		return true
+	}
+	if err == nil || httpStatus(err) != 400 {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "maximum context length") || strings.Contains(message, "context length exceeded") ||
+		strings.Contains(message, "context window")
+}
+
+// contextUpgrade returns the model to retry with when a prompt exceeds the
+// context of model. Dated snapshots match their longest prefix in
+// ContextUpgrades; an upgrade is only used if its context is larger.
+func (a *DevAgent) contextUpgrade(model string) (string, bool) {
+	best := ""
+	for name := range a.ContextUpgrades {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	upgrade := a.ContextUpgrades[best]
+	if upgrade == "" || contextLimit(upgrade) <= contextLimit(model) {
+		return "", false
+	}
+	return upgrade, true
+}
+
+// pruneContext returns a function that drops the largest half of the
+// context files on each call and renders the prompt with the rest. It
+// reports false once no context is left to drop.
+func pruneContext(context map[string]string, render func(context map[string]string) (string, error)) func() (string, bool) {
+	paths := make([]string, 0, len(context))
+	for filePath := range context {
+		paths = append(paths, filePath)
+	}
+	// Smallest first, so the largest files are dropped from the end
+	sort.Slice(paths, func(i, j int) bool {
+		if len(context[paths[i]]) != len(context[paths[j]]) {
+			return len(context[paths[i]]) < len(context[paths[j]])
+		}
+		return paths[i] < paths[j]
+	})
+
+	return func() (string, bool) {
+		if len(paths) == 0 {
+			return "", false
+		}
+		paths = paths[:len(paths)/2]
+		kept := make(map[string]string, len(paths))
+		for _, filePath := range paths {
+			kept[filePath] = context[filePath]
+		}
+		prompt, err := render(kept)
+		if err != nil {
+			return "", false
+		}
+		return prompt, true
+	}
+}
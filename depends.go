@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// dependsHint matches an ordering hint such as "depends: [src/types.ts]" in
+// a file description; the group holds the comma-separated paths.
+var dependsHint = regexp.MustCompile(`(?i)\s*\bdepends:\s*\[([^\]]*)\]`)
+
+// parseDependsHints returns description without its depends hints and the
+// paths they list, in order and without duplicates.
+func parseDependsHints(description string) (string, []string) {
+	var deps []string
+	seen := make(map[string]bool)
+	for _, m := range dependsHint.FindAllStringSubmatch(description, -1) {
+		for _, dep := range strings.Split(m[1], ",") {
+			dep = strings.Trim(strings.TrimSpace(dep), `"'`)
+			if dep != "" && !seen[dep] {
+				seen[dep] = true
+				deps = append(deps, dep)
+			}
+		}
+	}
+	if deps == nil {
+		return description, nil
+	}
+	return strings.TrimSpace(dependsHint.ReplaceAllString(description, "")), deps
+}
+
+// withoutDependsHints returns spec with the depends hints stripped from
+// its descriptions, so they never reach the model, and recorded in
+// dependencies. A spec without hints is returned as is.
+func withoutDependsHints(spec *ProjectSpec) *ProjectSpec {
+	var stripped *ProjectSpec
+	for filePath, description := range spec.Files {
+		description, deps := parseDependsHints(description)
+		if deps == nil {
+			continue
+		}
+		if stripped == nil {
+			copied := *spec
+			copied.Files = make(map[string]string, len(spec.Files))
+			for path, d := range spec.Files {
+				copied.Files[path] = d
+			}
+			copied.dependencies = make(map[string][]string)
+			stripped = &copied
+		}
+		stripped.Files[filePath] = description
+		stripped.dependencies[filePath] = deps
+	}
+	if stripped == nil {
+		return spec
+	}
+	return stripped
+}
+
+// orderByDependencies reorders filePaths so that each file comes after the
+// files it depends on, keeping the given order otherwise. Dependencies
+// outside filePaths are ignored, and a cycle is broken where it is found.
+func orderByDependencies(filePaths []string, dependencies map[string][]string) []string {
+	if len(dependencies) == 0 {
+		return filePaths
+	}
+	included := make(map[string]bool, len(filePaths))
+	for _, filePath := range filePaths {
+		included[filePath] = true
+	}
+
+	ordered := make([]string, 0, len(filePaths))
+	visited := make(map[string]bool, len(filePaths))
+	var visit func(filePath string)
+	visit = func(filePath string) {
+		if visited[filePath] || !included[filePath] {
+			return
+		}
+		visited[filePath] = true
+		for _, dep := range dependencies[filePath] {
+			visit(dep)
+		}
+		ordered = append(ordered, filePath)
+	}
+	for _, filePath := range filePaths {
+		visit(filePath)
+	}
+	return ordered
+}
+
+// dependencyProblems returns the depends hints of spec that list files
+// missing from the spec, and any dependency cycles.
+func dependencyProblems(spec *ProjectSpec) []string {
+	dependencies := make(map[string][]string)
+	var filePaths []string
+	for filePath, description := range spec.Files {
+		if _, deps := parseDependsHints(description); deps != nil {
+			dependencies[filePath] = deps
+			filePaths = append(filePaths, filePath)
+		}
+	}
+	sort.Strings(filePaths)
+
+	var problems []string
+	for _, filePath := range filePaths {
+		for _, dep := range dependencies[filePath] {
+			if _, ok := spec.Files[dep]; !ok {
+				problems = append(problems, fmt.Sprintf("file %s depends on %s, which is not in files", filePath, dep))
+			}
+		}
+	}
+
+	// Report each cycle once, from the first file on it in path order
+	const (
+		visiting = iota + 1
+		done
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(filePath string)
+	visit = func(filePath string) {
+		switch state[filePath] {
+		case visiting:
+			cycle := append(append([]string(nil), path[indexOf(path, filePath):]...), filePath)
+			problems = append(problems, fmt.Sprintf("dependency cycle: %s", strings.Join(cycle, " -> ")))
+			return
+		case done:
+			return
+		}
+		state[filePath] = visiting
+		path = append(path, filePath)
+		for _, dep := range dependencies[filePath] {
+			visit(dep)
+		}
+		path = path[:len(path)-1]
+		state[filePath] = done
+	}
+	for _, filePath := range filePaths {
+		visit(filePath)
+	}
+	return problems
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// dependencyManifests maps a language to the manifest file that lists its
+// third-party dependencies.
+var dependencyManifests = map[string]string{
+	"go":         "go.mod",
+	"javascript": "package.json",
+	"python":     "requirements.txt",
+}
+
+var (
+	goImportBlock  = regexp.MustCompile(`(?s)import\s*\((.*?)\)`)
+	goImportSingle = regexp.MustCompile(`(?m)^import\s+(?:[\w.]+\s+)?"([^"]+)"`)
+	goImportPath   = regexp.MustCompile(`"([^"]+)"`)
+	jsImport       = regexp.MustCompile(`(?:import\s+(?:[\w*{}\s,]+\s+from\s+)?|require\(\s*|import\(\s*)['"]([^'"]+)['"]`)
+	pyImport       = regexp.MustCompile(`(?m)^\s*(?:from\s+([\w.]+)\s+import|import\s+([\w.]+))`)
+)
+
+// languageForFile returns the language whose manifest covers the given file,
+// or an empty string if the file does not contribute dependencies.
+func languageForFile(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".go":
+		return "go"
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		return "javascript"
+	case ".py":
+		return "python"
+	}
+	return ""
+}
+
+// scanImports extracts the external packages referenced by a source file.
+// Relative imports and obvious standard library packages are skipped.
+func scanImports(language, content string) []string {
+	var imports []string
+	switch language {
+	case "go":
+		var paths []string
+		for _, block := range goImportBlock.FindAllStringSubmatch(content, -1) {
+			for _, m := range goImportPath.FindAllStringSubmatch(block[1], -1) {
+				paths = append(paths, m[1])
+			}
+		}
+		for _, m := range goImportSingle.FindAllStringSubmatch(content, -1) {
+			paths = append(paths, m[1])
+		}
+		for _, p := range paths {
+			// Standard library packages have no dot in their first element
+			if strings.Contains(strings.SplitN(p, "/", 2)[0], ".") {
+				imports = append(imports, p)
+			}
+		}
+	case "javascript":
+		for _, m := range jsImport.FindAllStringSubmatch(content, -1) {
+			p := m[1]
+			if strings.HasPrefix(p, ".") || strings.HasPrefix(p, "/") || strings.HasPrefix(p, "node:") {
+				continue
+			}
+			parts := strings.Split(p, "/")
+			if strings.HasPrefix(p, "@") && len(parts) > 1 {
+				imports = append(imports, parts[0]+"/"+parts[1])
+			} else {
+				imports = append(imports, parts[0])
+			}
+		}
+	case "python":
+		for _, m := range pyImport.FindAllStringSubmatch(content, -1) {
+			p := m[1] + m[2]
+			if p == "" || strings.HasPrefix(p, ".") {
+				continue
+			}
+			imports = append(imports, strings.Split(p, ".")[0])
+		}
+	}
+	return imports
+}
+
+// dependencyManifestPlan describes a manifest to generate: where it goes,
+// the packages it must list and its current content, if any.
+type dependencyManifestPlan struct {
+	Path     string
+	Name     string
+	Packages []string
+	Existing string
+	// Module is the module path of a Go workspace module
+	Module string
+}
+
+// planDependencyManifests scans the generated files for imports and plans a
+// manifest for every language used in the project.
+func planDependencyManifests(generatedFiles map[string]string) []dependencyManifestPlan {
+	// Collect the imports used by each language
+	imports := make(map[string]map[string]bool)
+	localModules := make(map[string]bool)
+	for filePath, content := range generatedFiles {
+		language := languageForFile(filePath)
+		if language == "" {
+			continue
+		}
+		if imports[language] == nil {
+			imports[language] = make(map[string]bool)
+		}
+		for _, imp := range scanImports(language, content) {
+			imports[language][imp] = true
+		}
+		// Files and directories of the project itself are not dependencies
+		for _, part := range strings.Split(filepath.ToSlash(filePath), "/") {
+			localModules[strings.TrimSuffix(part, path.Ext(part))] = true
+		}
+	}
+
+	var languages []string
+	for language := range imports {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	var plans []dependencyManifestPlan
+	for _, language := range languages {
+		plan := dependencyManifestPlan{Path: dependencyManifests[language], Name: dependencyManifests[language]}
+
+		for imp := range imports[language] {
+			if language == "python" && localModules[imp] {
+				continue
+			}
+			plan.Packages = append(plan.Packages, imp)
+		}
+		sort.Strings(plan.Packages)
+
+		// Update the manifest in place if the spec already generated one
+		for filePath, content := range generatedFiles {
+			if filepath.Base(filePath) == plan.Name {
+				plan.Path = filePath
+				plan.Existing = content
+				break
+			}
+		}
+		plans = append(plans, plan)
+	}
+	return plans
+}
+
+// generateDependencyManifest asks the model for the content of a planned
+// dependency manifest.
+func (a *DevAgent) generateDependencyManifest(spec *ProjectSpec, plan dependencyManifestPlan) (string, error) {
+	var existingSection string
+	if plan.Existing != "" {
+		existingSection = fmt.Sprintf("\nCurrent %s:\n```\n%s\n```\n", plan.Name, plan.Existing)
+	}
+	importInstruction := a.importInstruction(spec)
+	if plan.Module != "" {
+		importInstruction = fmt.Sprintf("- Declare the module as %s; it is part of a Go workspace, so do not require the workspace's other modules\n", plan.Module)
+	}
+
+	manifestPrompt := fmt.Sprintf(`Generate the complete %s for the %s project.
+Project Description: %s
+Framework: %s
+
+Packages imported by the generated code:
+%s
+%s
+Requirements:
+- List every third-party package imported by the code
+- Do not list standard library or project-local modules
+- Keep existing entries that are still needed
+- Use current, mutually compatible versions
+%s
+Generate only the file content, no explanations.`, plan.Name, spec.Name, spec.Description, spec.Framework, strings.Join(plan.Packages, "\n"), existingSection, importInstruction)
+
+	content, err := a.chat(chatRequest{
+		Label:       plan.Path,
+		Model:       a.CodeModel,
+		System:      "You are an expert programmer. Generate only the dependency manifest, no explanations or markdown.",
+		Prompt:      a.wrapCodePrompt(manifestPrompt),
+		Temperature: 0.2,
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("failed to generate %s: %v", plan.Path, err)
+	}
+
+	return a.finalizeContent(plan.Path, stripCodeFences(plan.Path, content)), nil
+}
+
+// GenerateDependencyManifests scans the generated files for imports and asks
+// the model to create or update the dependency manifest of every language
+// used in the project, so the code does not reference unlisted packages.
+// It returns the paths of the manifests it wrote.
+func (a *DevAgent) GenerateDependencyManifests(spec *ProjectSpec, projectDir string, generatedFiles map[string]string) ([]string, error) {
+	plans := planDependencyManifests(generatedFiles)
+	modules := goModules(spec)
+	if a.GoWorkspace && modules != nil {
+		goPlans, err := a.planGoModules(spec, modules, generatedFiles)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(plans); i++ {
+			if plans[i].Name == "go.mod" {
+				plans = append(plans[:i], plans[i+1:]...)
+				i--
+			}
+		}
+		plans = append(goPlans, plans...)
+	}
+
+	var written []string
+	for _, plan := range plans {
+		if a.keepExisting(projectDir, plan.Path) {
+			continue
+		}
+
+		done := a.startFile(plan.Path)
+		manifestContent, err := a.generateDependencyManifest(spec, plan)
+		if err != nil {
+			return written, err
+		}
+
+		generatedFiles[plan.Path] = manifestContent
+
+		err = writeProjectFile(projectDir, plan.Path, manifestContent)
+		if err != nil {
+			return written, err
+		}
+		written = append(written, plan.Path)
+		done()
+	}
+
+	if a.GoWorkspace && modules != nil {
+		if _, planned := spec.Files[goWorkName]; !planned && !a.keepExisting(projectDir, goWorkName) {
+			done := a.startFile(goWorkName)
+			content := goWork(modules, generatedFiles)
+			generatedFiles[goWorkName] = content
+			if err := writeProjectFile(projectDir, goWorkName, content); err != nil {
+				return written, err
+			}
+			written = append(written, goWorkName)
+			done()
+		}
+	}
+
+	return written, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// diagramPath is the architecture document written with Diagram.
+const diagramPath = "docs/architecture.md"
+
+// maxDiagramImports caps the import lines sent per file.
+const maxDiagramImports = 20
+
+// importLinePattern matches lines that import another module in the
+// languages the generator commonly produces.
+var importLinePattern = regexp.MustCompile(`(?m)^\s*(?:import\b.*|from\s+\S+\s+import\b.*|.*\brequire\(.*|#include\b.*|use\s+[\w:{}, ]+;)$`)
+
+// importLines returns the import lines of content, which show how the
+// file depends on the others.
+func importLines(content string) []string {
+	lines := importLinePattern.FindAllString(content, maxDiagramImports)
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return lines
+}
+
+// GenerateDiagram asks the model for a Mermaid diagram of the project's
+// components and their relationships, derived from the spec and the
+// imports of the generated files, and writes it to docs/architecture.md.
+// It returns an empty path if the spec already plans that file.
+func (a *DevAgent) GenerateDiagram(spec *ProjectSpec, projectDir string, generatedFiles map[string]string) (string, error) {
+	if _, ok := generatedFiles[diagramPath]; ok {
+		return "", nil
+	}
+	if a.keepExisting(projectDir, diagramPath) {
+		return "", nil
+	}
+
+	filePaths := make([]string, 0, len(generatedFiles))
+	for filePath := range generatedFiles {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+	var structure strings.Builder
+	for _, filePath := range filePaths {
+		structure.WriteString("\n" + filePath)
+		if description := spec.Files[filePath]; description != "" {
+			structure.WriteString(": " + description)
+		}
+		structure.WriteString("\n")
+		for _, line := range importLines(generatedFiles[filePath]) {
+			structure.WriteString("    " + line + "\n")
+		}
+	}
+
+	done := a.startFile(diagramPath)
+
+	diagramPrompt := fmt.Sprintf(`Write an architecture document in markdown for the %s project.
+Project Description: %s
+Framework: %s
+Components: %s
+
+Project files with their imports:
+%s
+Requirements:
+- Start with a "# Architecture" heading and a short overview of how the project is structured
+- Include a Mermaid flowchart in a `+"```mermaid"+` code block showing the components and how they depend on and call each other
+- Group files into the components they belong to rather than drawing every file
+- Follow the diagram with a short description of each component
+Generate only the document, no explanations.`, spec.Name, spec.Description, spec.Framework, strings.Join(spec.Components, ", "), structure.String())
+
+	content, err := a.chat(chatRequest{
+		Label:       diagramPath,
+		Model:       a.ReadmeModel,
+		System:      "You are an expert software architect who documents systems with clear Mermaid diagrams.",
+		Prompt:      diagramPrompt,
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate %s: %v", diagramPath, err)
+	}
+
+	content = stripCodeFences(diagramPath, content)
+	if !strings.Contains(content, "```mermaid") {
+		return "", fmt.Errorf("failed to generate %s: the reply has no Mermaid diagram", diagramPath)
+	}
+	content = a.finalizeContent(diagramPath, content)
+	generatedFiles[diagramPath] = content
+
+	if err := writeProjectFile(projectDir, diagramPath, content); err != nil {
+		return "", err
+	}
+	done()
+	return diagramPath, nil
+}
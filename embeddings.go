@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// embeddingsCacheName is the file, relative to the project directory, that
+// caches description embeddings between runs.
+const embeddingsCacheName = ".ashutosh-embeddings.json"
+
+// defaultEmbeddingModel embeds file descriptions for context selection.
+const defaultEmbeddingModel = openai.SmallEmbedding3
+
+// embedder is implemented by providers that can create embeddings.
+type embedder interface {
+	CreateEmbeddings(ctx context.Context, req openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error)
+}
+
+func (p *compatibleProvider) CreateEmbeddings(ctx context.Context, req openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error) {
+	return p.client.CreateEmbeddings(ctx, req)
+}
+
+// readEmbeddingsCache loads cached embeddings keyed by model and description
+// hash. A missing or unreadable cache is treated as empty, as is the cache
+// of a project without a directory.
+func readEmbeddingsCache(projectDir string) map[string][]float32 {
+	cache := make(map[string][]float32)
+	if projectDir == "" {
+		return cache
+	}
+	data, err := os.ReadFile(filepath.Join(projectDir, embeddingsCacheName))
+	if err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	return cache
+}
+
+// embedDescriptions returns an embedding of every file description in the
+// spec, keyed by file path. Descriptions already in the project's cache are
+// not sent again; with an empty projectDir nothing is cached.
+func (a *DevAgent) embedDescriptions(spec *ProjectSpec, projectDir string) (map[string][]float32, error) {
+	var provider embedder
+	for _, p := range a.Providers {
+		if e, ok := p.(embedder); ok {
+			provider = e
+			break
+		}
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("no configured provider supports embeddings")
+	}
+
+	cache := readEmbeddingsCache(projectDir)
+	key := func(filePath string) string {
+		return string(a.EmbeddingModel) + ":" + descriptionHash(spec.Files[filePath])
+	}
+
+	var missing []string
+	for filePath := range spec.Files {
+		if _, ok := cache[key(filePath)]; !ok {
+			missing = append(missing, filePath)
+		}
+	}
+	sort.Strings(missing)
+
+	if len(missing) > 0 {
+		inputs := make([]string, len(missing))
+		for i, filePath := range missing {
+			inputs[i] = filePath + ": " + spec.Files[filePath]
+		}
+		a.Log.Debugf("Embedding %d file description(s) with %s", len(missing), a.EmbeddingModel)
+		resp, err := provider.CreateEmbeddings(a.ctx, openai.EmbeddingRequest{
+			Input: inputs,
+			Model: a.EmbeddingModel,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create embeddings: %v", err)
+		}
+		a.Usage.Record("embeddings", string(a.EmbeddingModel), resp.Usage)
+		for _, embedding := range resp.Data {
+			if embedding.Index < len(missing) {
+				cache[key(missing[embedding.Index])] = embedding.Embedding
+			}
+		}
+	}
+
+	// Only descriptions of the current spec are kept in the cache
+	embeddings := make(map[string][]float32, len(spec.Files))
+	current := make(map[string][]float32, len(spec.Files))
+	for filePath := range spec.Files {
+		if embedding, ok := cache[key(filePath)]; ok {
+			embeddings[filePath] = embedding
+			current[key(filePath)] = embedding
+		}
+	}
+
+	if len(missing) > 0 && projectDir != "" {
+		data, err := json.Marshal(current)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(projectDir, embeddingsCacheName), data, 0644)
+		}
+		if err != nil {
+			a.Log.Warnf("Could not cache embeddings: %v", err)
+		}
+	}
+	return embeddings, nil
+}
+
+// cosineSimilarity returns the cosine of the angle between two vectors.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// mostSimilar returns up to k of the candidate files whose embeddings are
+// closest to that of filePath, most similar first. Candidates without an
+// embedding are not considered.
+func mostSimilar(embeddings map[string][]float32, filePath string, candidates []string, k int) []string {
+	target, ok := embeddings[filePath]
+	if !ok {
+		return nil
+	}
+
+	type scored struct {
+		path  string
+		score float64
+	}
+	var ranked []scored
+	for _, candidate := range candidates {
+		if embedding, ok := embeddings[candidate]; ok && candidate != filePath {
+			ranked = append(ranked, scored{candidate, cosineSimilarity(target, embedding)})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	var selected []string
+	for i := 0; i < len(ranked) && i < k; i++ {
+		selected = append(selected, ranked[i].path)
+	}
+	return selected
+}
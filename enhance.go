@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// defaultEnhanceModel is the cheap model that expands terse prompts.
+const defaultEnhanceModel = openai.GPT4oMini
+
+// EnhancePrompt asks EnhanceModel to expand a terse project description
+// into a detailed requirements paragraph for GenerateProjectSpec.
+func (a *DevAgent) EnhancePrompt(prompt string) (string, error) {
+	enhancePrompt := fmt.Sprintf(`Rewrite the following project request as one detailed requirements paragraph for a developer.
+Keep everything the user asked for and do not change their choices. Where the request is vague, state
+reasonable assumptions about the features, the user interface, data storage, the technologies and how the
+project is run. Respond only with the paragraph.
+
+Request: %s`, prompt)
+
+	enhanced, err := a.chat(chatRequest{
+		Label:       "prompt",
+		Model:       a.EnhanceModel,
+		System:      "You are an experienced software architect who turns brief ideas into clear project requirements.",
+		Prompt:      enhancePrompt,
+		Temperature: 0.3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to enhance prompt: %v", err)
+	}
+	enhanced = strings.TrimSpace(enhanced)
+	if enhanced == "" {
+		return "", fmt.Errorf("failed to enhance prompt: the model returned an empty reply")
+	}
+	return enhanced, nil
+}
+
+// enhanceInput shows the enhanced version of input and, when ask is set,
+// asks whether to use it. The original input is kept if enhancing fails
+// or the user declines.
+func enhanceInput(agent *DevAgent, input string, reader *bufio.Reader, ask bool) string {
+	fmt.Fprintln(stdout, "\n🪄 Enhancing prompt...")
+	enhanced, err := agent.EnhancePrompt(input)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error enhancing prompt, using it as given: %v\n", err)
+		return input
+	}
+
+	fmt.Fprintln(stdout, "\n📝 Enhanced prompt:")
+	fmt.Fprintln(stdout, enhanced)
+	if !ask {
+		return enhanced
+	}
+
+	fmt.Fprint(stdout, "\nUse the enhanced prompt? (y/n): ")
+	use, _ := reader.ReadString('\n')
+	if strings.TrimSpace(strings.ToLower(use)) != "y" {
+		return input
+	}
+	return enhanced
+}
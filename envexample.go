@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// envExampleName is the environment example file written to the project
+// root.
+const envExampleName = ".env.example"
+
+// envReferences match reads of an environment variable in the languages the
+// generator commonly produces; the first group is the variable name.
+var envReferences = []*regexp.Regexp{
+	// Go, Python, PHP, C and Java
+	regexp.MustCompile(`\b(?:Getenv|LookupEnv|getenv|environ\.get|getEnv|GetEnvironmentVariable)\(\s*["']([A-Za-z_][A-Za-z0-9_]*)["']`),
+	// Python, Ruby and PHP
+	regexp.MustCompile(`(?:\bos\.environ|\bENV|\$_ENV|\$_SERVER)\[\s*["']([A-Za-z_][A-Za-z0-9_]*)["']\s*\]`),
+	regexp.MustCompile(`\bENV\.fetch\(\s*["']([A-Za-z_][A-Za-z0-9_]*)["']`),
+	// JavaScript and TypeScript
+	regexp.MustCompile(`\b(?:process|import\.meta)\.env\.([A-Za-z_][A-Za-z0-9_]*)`),
+	regexp.MustCompile(`\b(?:process|import\.meta)\.env\[\s*["']([A-Za-z_][A-Za-z0-9_]*)["']\s*\]`),
+	// Rust
+	regexp.MustCompile(`\benv::var(?:_os)?\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`),
+}
+
+// systemEnvVars are set by the operating system or shell and do not belong
+// in a project's environment file.
+var systemEnvVars = map[string]bool{
+	"HOME": true, "PATH": true, "USER": true, "SHELL": true, "PWD": true, "TMPDIR": true, "TEMP": true, "TMP": true,
+}
+
+// envPlaceholder returns an example value for an environment variable,
+// guessed from its name.
+func envPlaceholder(name string) string {
+	upper := strings.ToUpper(name)
+	switch {
+	case strings.Contains(upper, "PORT"):
+		return "8080"
+	case strings.HasSuffix(upper, "HOST"):
+		return "localhost"
+	case strings.Contains(upper, "URL") || strings.Contains(upper, "URI") || strings.Contains(upper, "DSN"):
+		return "http://localhost"
+	case upper == "NODE_ENV" || upper == "APP_ENV" || upper == "ENV" || upper == "ENVIRONMENT":
+		return "development"
+	case strings.Contains(upper, "DEBUG"):
+		return "false"
+	case strings.Contains(upper, "KEY") || strings.Contains(upper, "SECRET") || strings.Contains(upper, "TOKEN") ||
+		strings.Contains(upper, "PASSWORD") || strings.Contains(upper, "PASS"):
+		return "changeme"
+	}
+	return ""
+}
+
+// scanEnvVars returns the environment variables read by the given files,
+// each with the sorted paths of the files that read it.
+func scanEnvVars(files map[string]string) map[string][]string {
+	usedBy := make(map[string]map[string]bool)
+	for filePath, content := range files {
+		for _, re := range envReferences {
+			for _, m := range re.FindAllStringSubmatch(content, -1) {
+				name := m[1]
+				if systemEnvVars[name] {
+					continue
+				}
+				if usedBy[name] == nil {
+					usedBy[name] = make(map[string]bool)
+				}
+				usedBy[name][filePath] = true
+			}
+		}
+	}
+
+	vars := make(map[string][]string, len(usedBy))
+	for name, paths := range usedBy {
+		for filePath := range paths {
+			vars[name] = append(vars[name], filePath)
+		}
+		sort.Strings(vars[name])
+	}
+	return vars
+}
+
+// envExample renders a .env.example listing the environment variables the
+// generated files read, with a comment naming the files that use each one.
+// It returns an empty string if no variables are read.
+func envExample(spec *ProjectSpec, files map[string]string) string {
+	vars := scanEnvVars(files)
+	if len(vars) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Environment variables for %s.\n", spec.Name)
+	b.WriteString("# Copy this file to .env and replace the example values.\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n# Used by %s\n%s=%s\n", strings.Join(vars[name], ", "), name, envPlaceholder(name))
+	}
+	return b.String()
+}
+
+// GenerateEnvExample writes a .env.example for the environment variables
+// the generated code reads to the project root. It returns an empty path if
+// the spec already plans the file or the code reads no variables.
+func (a *DevAgent) GenerateEnvExample(spec *ProjectSpec, projectDir string, generatedFiles map[string]string) (string, error) {
+	if _, ok := generatedFiles[envExampleName]; ok {
+		return "", nil
+	}
+	if a.keepExisting(projectDir, envExampleName) {
+		return "", nil
+	}
+
+	content := envExample(spec, generatedFiles)
+	if content == "" {
+		a.Log.Infof("⏭️  Skipping %s: the code reads no environment variables", envExampleName)
+		return "", nil
+	}
+
+	done := a.startFile(envExampleName)
+	generatedFiles[envExampleName] = content
+	if err := writeProjectFile(projectDir, envExampleName, content); err != nil {
+		return "", err
+	}
+	done()
+	return envExampleName, nil
+}
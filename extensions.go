@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultFrameworkExtensions maps framework and language keywords, matched
+// against the spec's framework and type, to the source file extensions a
+// project using them is expected to contain. The mapping can be extended
+// with "framework_extensions" in the config file.
+var defaultFrameworkExtensions = map[string][]string{
+	"react":   {".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs", ".css", ".scss"},
+	"next":    {".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs", ".css", ".scss"},
+	"vue":     {".js", ".ts", ".vue", ".mjs", ".cjs", ".css", ".scss"},
+	"angular": {".ts", ".js", ".css", ".scss"},
+	"svelte":  {".js", ".ts", ".svelte", ".css"},
+	"express": {".js", ".ts", ".mjs", ".cjs"},
+	"nest":    {".ts", ".js"},
+	"node":    {".js", ".ts", ".mjs", ".cjs"},
+	"django":  {".py"},
+	"flask":   {".py"},
+	"fastapi": {".py"},
+	"python":  {".py"},
+	"gin":     {".go", ".tmpl"},
+	"echo":    {".go", ".tmpl"},
+	"fiber":   {".go", ".tmpl"},
+	"go":      {".go", ".tmpl"},
+	"spring":  {".java", ".kt"},
+	"java":    {".java"},
+	"kotlin":  {".kt", ".kts"},
+	"rails":   {".rb", ".erb"},
+	"ruby":    {".rb"},
+	"laravel": {".php"},
+	"php":     {".php"},
+	"rust":    {".rs"},
+	"actix":   {".rs"},
+	"axum":    {".rs"},
+	".net":    {".cs", ".cshtml", ".razor"},
+	"flutter": {".dart"},
+}
+
+// checkFileExtensions cross-checks the spec's source files against the
+// extensions expected for its framework and type. It returns one message
+// per mismatched file. Files whose extension does not belong to any known
+// framework, such as configuration and docs, are never reported.
+func checkFileExtensions(spec *ProjectSpec, mapping map[string][]string) []string {
+	declared := strings.ToLower(spec.Framework + " " + spec.Type)
+
+	allowed := make(map[string]bool)
+	known := make(map[string]bool)
+	for keyword, extensions := range mapping {
+		matched := containsWord(declared, keyword)
+		for _, ext := range extensions {
+			known[ext] = true
+			if matched {
+				allowed[ext] = true
+			}
+		}
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	var mismatches []string
+	for filePath := range spec.Files {
+		ext := strings.ToLower(filepath.Ext(filePath))
+		if known[ext] && !allowed[ext] {
+			mismatches = append(mismatches, fmt.Sprintf("%s does not match the declared framework %s", filePath, spec.Framework))
+		}
+	}
+	sort.Strings(mismatches)
+	return mismatches
+}
+
+// containsWord reports whether keyword occurs in text delimited by
+// non-alphanumeric characters, so "go" does not match "django".
+func containsWord(text, keyword string) bool {
+	for i := 0; ; {
+		idx := strings.Index(text[i:], keyword)
+		if idx < 0 {
+			return false
+		}
+		start, end := i+idx, i+idx+len(keyword)
+		if (start == 0 || !isAlphanumeric(text[start-1])) && (end == len(text) || !isAlphanumeric(text[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
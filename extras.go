@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// extras are the optional outputs selectable with -extras, with what each
+// one adds to the project.
+var extras = []struct {
+	Name        string
+	Description string
+}{
+	{"readme", "README.md describing the project"},
+	{"tests", "a test file alongside each source file, as with -with-tests"},
+	{"makefile", "a Makefile with build, test, run and lint targets, as with -makefile"},
+	{"ci", "a CI workflow, for GitHub unless -ci names another provider"},
+	{"env-example", "a .env.example of the environment variables the code reads, as with -env-example"},
+	{"diagram", "docs/architecture.md with a Mermaid diagram, as with -diagram"},
+	{"community-files", "CONTRIBUTING.md and the other community files, as with -community-files"},
+	{"k8s", "Kubernetes manifests under k8s/, as with -k8s"},
+}
+
+// defaultCIProvider is the CI provider of the ci extra unless -ci is set.
+const defaultCIProvider = "github"
+
+// extraNames returns the names accepted by -extras in the order they are
+// listed.
+func extraNames() []string {
+	names := make([]string, len(extras))
+	for i, extra := range extras {
+		names[i] = extra.Name
+	}
+	return names
+}
+
+// describeExtras lists the available extras, one per line.
+func describeExtras() string {
+	var b strings.Builder
+	for _, extra := range extras {
+		fmt.Fprintf(&b, "  %-16s %s\n", extra.Name, extra.Description)
+	}
+	return b.String()
+}
+
+// parseExtras parses a comma-separated list of extras into the set of
+// selected names.
+func parseExtras(value string) (map[string]bool, error) {
+	known := make(map[string]bool, len(extras))
+	for _, extra := range extras {
+		known[extra.Name] = true
+	}
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("unknown extra %q", name)
+		}
+		selected[name] = true
+	}
+	return selected, nil
+}
+
+// applyExtras turns on the selected extras in addition to those enabled by
+// their own flags. The README is only generated when selected.
+func (a *DevAgent) applyExtras(selected map[string]bool) {
+	a.NoReadme = !selected["readme"]
+	a.WithTests = a.WithTests || selected["tests"]
+	a.Makefile = a.Makefile || selected["makefile"]
+	if selected["ci"] && a.CI == "" {
+		a.CI = defaultCIProvider
+	}
+	a.EnvExample = a.EnvExample || selected["env-example"]
+	a.Diagram = a.Diagram || selected["diagram"]
+	a.CommunityFiles = a.CommunityFiles || selected["community-files"]
+	a.K8s = a.K8s || selected["k8s"]
+}
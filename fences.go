@@ -0,0 +1,104 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// markdownExtensions are files whose content is markdown, so code fences
+// inside them are part of the file.
+var markdownExtensions = map[string]bool{".md": true, ".markdown": true, ".mdx": true}
+
+// stripCodeFences recovers the content of filePath from a model response.
+// A response wrapped in a single code block is unwrapped. A chatty response
+// that starts with prose or holds several code blocks is reduced to its
+// code blocks, joined in order. Markdown files keep inner fences and only
+// lose a surrounding one.
+func stripCodeFences(filePath, content string) string {
+	content = strings.TrimSpace(content)
+	if markdownExtensions[strings.ToLower(filepath.Ext(filePath))] {
+		// A document ending in a code block keeps its closing fence
+		if !strings.HasPrefix(content, "```") {
+			return content
+		}
+		return stripSurroundingFence(content)
+	}
+
+	blocks, prose := codeBlocks(content)
+	if len(blocks) == 0 || (!strings.HasPrefix(content, "```") && !startsWithProse(filePath, content)) {
+		return stripSurroundingFence(content)
+	}
+	if len(blocks) == 1 && !prose {
+		return strings.TrimSpace(blocks[0])
+	}
+	for i, block := range blocks {
+		blocks[i] = strings.TrimSpace(block)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// stripSurroundingFence removes a markdown code block around the whole
+// content and its language identifier (e.g., ```javascript).
+func stripSurroundingFence(content string) string {
+	fenced := strings.HasPrefix(content, "```")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+
+	// Remove language identifier if present (e.g., ```javascript)
+	if idx := strings.Index(content, "\n"); fenced && idx != -1 {
+		if !strings.Contains(content[:idx], "=") && !strings.Contains(content[:idx], ":") {
+			content = content[idx+1:]
+		}
+	}
+	return strings.TrimSpace(content)
+}
+
+// codeBlocks returns the bodies of the fenced code blocks in content and
+// whether there is other text around them. A block left open at the end,
+// as in a truncated response, runs to the end of the content.
+func codeBlocks(content string) (blocks []string, prose bool) {
+	var block []string
+	inBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case !inBlock && strings.HasPrefix(trimmed, "```"):
+			inBlock = true
+			block = nil
+		case inBlock && trimmed == "```":
+			inBlock = false
+			blocks = append(blocks, strings.Join(block, "\n"))
+		case inBlock:
+			block = append(block, line)
+		case trimmed != "":
+			prose = true
+		}
+	}
+	if inBlock {
+		blocks = append(blocks, strings.Join(block, "\n"))
+	}
+	return blocks, prose
+}
+
+// startsWithProse reports whether the response opens with a sentence
+// introducing code, such as "Here is the updated file:", rather than with
+// code itself. A sentence in a comment of filePath's language, such as a
+// Python module comment, is code.
+func startsWithProse(filePath, content string) bool {
+	firstLine, _, _ := strings.Cut(content, "\n")
+	firstLine = strings.TrimSpace(firstLine)
+	if style, ok := commentStyleFor(filePath); ok {
+		for _, marker := range []string{style.Start, strings.TrimSpace(style.Line)} {
+			if marker != "" && strings.HasPrefix(firstLine, marker) {
+				return false
+			}
+		}
+	}
+	if !strings.HasSuffix(firstLine, ":") && !strings.HasSuffix(firstLine, ".") {
+		return false
+	}
+	if strings.ContainsAny(firstLine, ";{}=<>") {
+		return false
+	}
+	return len(strings.Fields(firstLine)) >= 3
+}
@@ -0,0 +1,80 @@
+package main
+
+import "testing"
+
+func TestStripCodeFences(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		content  string
+		want     string
+	}{
+		{
+			name:     "plain code",
+			filePath: "main.go",
+			content:  "package main\n",
+			want:     "package main",
+		},
+		{
+			name:     "single fenced block",
+			filePath: "main.go",
+			content:  "```go\npackage main\n```",
+			want:     "package main",
+		},
+		{
+			name:     "prose-wrapped block",
+			filePath: "main.go",
+			content:  "Here is the updated file:\n\n```go\npackage main\n```\n\nLet me know if you need anything else.",
+			want:     "package main",
+		},
+		{
+			name:     "several blocks joined in order",
+			filePath: "app.js",
+			content:  "Here is the code for the file:\n```js\nconst a = 1;\n```\nAnd the export:\n```js\nmodule.exports = a;\n```",
+			want:     "const a = 1;\n\nmodule.exports = a;",
+		},
+		{
+			name:     "several fenced blocks without prose",
+			filePath: "app.js",
+			content:  "```js\nconst a = 1;\n```\n```js\nconst b = 2;\n```",
+			want:     "const a = 1;\n\nconst b = 2;",
+		},
+		{
+			name:     "truncated block",
+			filePath: "main.go",
+			content:  "Here is the complete file:\n```go\npackage main\n\nfunc main() {",
+			want:     "package main\n\nfunc main() {",
+		},
+		{
+			name:     "comment sentence before a docstring with a fence",
+			filePath: "users.py",
+			content:  "# This module handles users.\n\ndef load():\n    \"\"\"Load users.\n\n    ```\n    load()\n    ```\n    \"\"\"\n",
+			want:     "# This module handles users.\n\ndef load():\n    \"\"\"Load users.\n\n    ```\n    load()\n    ```\n    \"\"\"",
+		},
+		{
+			name:     "block comment sentence",
+			filePath: "style.css",
+			content:  "/* Styles for the page. */\nbody { margin: 0; }\n/* Example:\n```\n<body>\n```\n*/",
+			want:     "/* Styles for the page. */\nbody { margin: 0; }\n/* Example:\n```\n<body>\n```\n*/",
+		},
+		{
+			name:     "markdown keeps inner fences",
+			filePath: "README.md",
+			content:  "# App\n\n```sh\nmake run\n```",
+			want:     "# App\n\n```sh\nmake run\n```",
+		},
+		{
+			name:     "markdown loses a surrounding fence",
+			filePath: "README.md",
+			content:  "```markdown\n# App\n```",
+			want:     "# App",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripCodeFences(tt.filePath, tt.content); got != tt.want {
+				t.Errorf("stripCodeFences(%q, %q) =\n%q\nwant\n%q", tt.filePath, tt.content, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// externalFormatters maps file extensions to a formatter command that
+// reads the file from stdin and writes the formatted file to stdout. The
+// file path is appended where a formatter needs it to pick a parser.
+var externalFormatters = map[string][]string{
+	".js":   {"prettier", "--stdin-filepath"},
+	".jsx":  {"prettier", "--stdin-filepath"},
+	".ts":   {"prettier", "--stdin-filepath"},
+	".tsx":  {"prettier", "--stdin-filepath"},
+	".css":  {"prettier", "--stdin-filepath"},
+	".scss": {"prettier", "--stdin-filepath"},
+	".html": {"prettier", "--stdin-filepath"},
+	".json": {"prettier", "--stdin-filepath"},
+	".py":   {"black", "--quiet", "-"},
+	".rs":   {"rustfmt", "--emit", "stdout"},
+}
+
+// ensureTrailingNewline makes non-empty content end in exactly one newline.
+func ensureTrailingNewline(content string) string {
+	trimmed := strings.TrimRight(content, "\r\n")
+	if trimmed == "" {
+		return ""
+	}
+	return trimmed + "\n"
+}
+
+// formatContent formats content with the standard formatter for the file's
+// language. Go is formatted in-process; other languages need their
+// formatter on the PATH. Content without an available formatter is
+// returned unchanged.
+func (a *DevAgent) formatContent(filePath, content string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext == ".go" {
+		formatted, err := format.Source([]byte(content))
+		if err != nil {
+			return content, err
+		}
+		return string(formatted), nil
+	}
+
+	command, ok := externalFormatters[ext]
+	if !ok {
+		return content, nil
+	}
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return content, nil
+	}
+	args := append([]string(nil), command[1:]...)
+	if args[len(args)-1] == "--stdin-filepath" {
+		args = append(args, filePath)
+	}
+
+	cmd := exec.CommandContext(a.ctx, command[0], args...)
+	cmd.Stdin = strings.NewReader(content)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return content, fmt.Errorf("%s: %v %s", command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
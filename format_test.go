@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestEnsureTrailingNewline(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"without newline", "package main", "package main\n"},
+		{"with newline", "package main\n", "package main\n"},
+		{"with several newlines", "package main\n\n\n", "package main\n"},
+		{"with CRLF", "package main\r\n", "package main\n"},
+		{"inner blank lines kept", "a\n\nb", "a\n\nb\n"},
+		{"empty", "", ""},
+		{"only newlines", "\n\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ensureTrailingNewline(tt.content); got != tt.want {
+				t.Errorf("ensureTrailingNewline(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
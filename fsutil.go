@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// recoveryNoteName is written to the project directory when generation
+// aborts because of a filesystem error.
+const recoveryNoteName = "RECOVERY.md"
+
+// describeFSError turns a filesystem error into an actionable message that
+// names the affected path and the likely cause.
+func describeFSError(action, path string, err error) error {
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("permission denied while trying to %s %s: check that the directory is writable", action, path)
+	case errors.Is(err, syscall.EROFS):
+		return fmt.Errorf("cannot %s %s: the file system is read-only", action, path)
+	case errors.Is(err, syscall.ENOSPC):
+		return fmt.Errorf("no space left on device while trying to %s %s: free up disk space and re-run", action, path)
+	}
+	return fmt.Errorf("failed to %s %s: %v", action, path, err)
+}
+
+// writeProjectFile writes content to filePath inside projectDir, creating
+// any missing parent directories.
+func writeProjectFile(projectDir, filePath, content string) error {
+	fullPath := filepath.Join(projectDir, filePath)
+	err := os.MkdirAll(filepath.Dir(fullPath), 0755)
+	if err != nil {
+		return describeFSError("create directories for", filePath, err)
+	}
+
+	err = os.WriteFile(fullPath, []byte(content), 0644)
+	if err != nil {
+		return describeFSError("write", filePath, err)
+	}
+	return nil
+}
+
+// writeRecoveryNote records which files were written before generation
+// stopped so the user can tell partial output from a finished project.
+// Failures are ignored since the disk may be the cause of the abort.
+func writeRecoveryNote(projectDir string, written, pending []string, cause error) string {
+	var note strings.Builder
+	note.WriteString("# Generation interrupted\n\n")
+	note.WriteString(fmt.Sprintf("Generation stopped: %v\n", cause))
+	note.WriteString("\n## Files written\n\n")
+	for _, filePath := range written {
+		note.WriteString(fmt.Sprintf("- %s\n", filePath))
+	}
+	note.WriteString("\n## Files not written\n\n")
+	for _, filePath := range pending {
+		note.WriteString(fmt.Sprintf("- %s\n", filePath))
+	}
+	note.WriteString("\nFix the problem above and run the generator again, or remove this directory.\n")
+
+	notePath := filepath.Join(projectDir, recoveryNoteName)
+	if err := os.WriteFile(notePath, []byte(note.String()), 0644); err != nil {
+		return ""
+	}
+	return notePath
+}
+
+// keepExisting reports whether filePath already exists in projectDir and
+// must be left untouched because SkipExisting is set.
+func (a *DevAgent) keepExisting(projectDir, filePath string) bool {
+	if !a.SkipExisting {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, filePath)); err != nil {
+		return false
+	}
+	a.Log.Infof("⏭️  Keeping existing %s", filePath)
+	return true
+}
+
+// Policies for a project directory that already exists.
+const (
+	onExistsMerge     = "merge"
+	onExistsOverwrite = "overwrite"
+	onExistsSkip      = "skip"
+	onExistsError     = "error"
+)
+
+// onExistsPolicies lists the accepted -on-exists values.
+var onExistsPolicies = []string{onExistsMerge, onExistsOverwrite, onExistsSkip, onExistsError}
+
+// isOnExistsPolicy reports whether policy is a known -on-exists value.
+func isOnExistsPolicy(policy string) bool {
+	for _, known := range onExistsPolicies {
+		if policy == known {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareProjectDir applies the OnExists policy to the directory of spec,
+// projectDir, and creates it. It returns false if generation should be
+// skipped. Incremental and resumed runs build on the existing files and
+// therefore always merge.
+func (a *DevAgent) prepareProjectDir(spec *ProjectSpec, projectDir string) (bool, error) {
+	entries, err := os.ReadDir(projectDir)
+	exists := err == nil && len(entries) > 0
+
+	if exists && !a.Incremental && a.ResumeFrom == "" {
+		switch a.OnExists {
+		case onExistsError:
+			return false, fmt.Errorf("project directory %s already exists (use -on-exists merge, overwrite or skip)", projectDir)
+		case onExistsSkip:
+			a.Log.Infof("⏭️  Skipping generation: %s already exists", projectDir)
+			return false, nil
+		case onExistsOverwrite:
+			if err := a.checkRemovable(spec, projectDir); err != nil {
+				return false, err
+			}
+			a.Log.Warnf("Removing existing project directory %s", projectDir)
+			if err := os.RemoveAll(projectDir); err != nil {
+				return false, describeFSError("remove project directory", projectDir, err)
+			}
+		}
+	}
+
+	err = os.MkdirAll(projectDir, 0755)
+	if err != nil {
+		return false, describeFSError("create project directory", projectDir, err)
+	}
+	return true, nil
+}
+
+// checkRemovable guards removing projectDir for -on-exists overwrite: a
+// project name such as "." or ".." would otherwise remove the output
+// directory or one above it. Only a directory strictly below OutputDir,
+// named by a single path element, may be removed.
+func (a *DevAgent) checkRemovable(spec *ProjectSpec, projectDir string) error {
+	refuse := fmt.Errorf("refusing to remove %s: it is not a project directory below the output directory", projectDir)
+	if !filepath.IsLocal(spec.Name) || strings.ContainsAny(spec.Name, `/\`) {
+		return refuse
+	}
+	outputDir, err := filepath.Abs(a.OutputDir)
+	if err != nil {
+		return refuse
+	}
+	dir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return refuse
+	}
+	rel, err := filepath.Rel(outputDir, dir)
+	if err != nil || rel == "." || !filepath.IsLocal(rel) {
+		return refuse
+	}
+	return nil
+}
+
+// stopBefore ends a run whose MaxRuntime passed after its files were
+// generated, before the phase producing what is named: the manifest of the
+// files written so far is saved and the phase is returned in an error. It
+// returns nil while time remains.
+func (a *DevAgent) stopBefore(projectDir string, run *generationRun, what string) error {
+	if !run.expired() {
+		return nil
+	}
+	a.Log.Warnf("Stopped after the maximum runtime of %s before generating the %s", a.MaxRuntime, what)
+	if err := run.manifest.Write(projectDir); err != nil {
+		return err
+	}
+	return fmt.Errorf("%v after %s: stopped before generating the %s", errMaxRuntime, a.MaxRuntime, what)
+}
+
+// stopAtMaxRuntime ends a run stopped by MaxRuntime: the manifest of the
+// files written so far is saved, so an incremental run can pick up where
+// this one stopped, and the progress is returned as an error.
+func (a *DevAgent) stopAtMaxRuntime(projectDir string, run *generationRun, toGenerate []string) error {
+	pending := run.notWritten(toGenerate)
+	done := len(toGenerate) - len(pending)
+	a.Log.Warnf("Stopped after the maximum runtime of %s with %d of %d file(s) generated", a.MaxRuntime, done, len(toGenerate))
+	if !run.inMemory {
+		if err := run.manifest.Write(projectDir); err != nil {
+			return err
+		}
+		writeRecoveryNote(projectDir, run.written, pending, errMaxRuntime)
+	}
+	return fmt.Errorf("%v after %s: %d file(s) not generated (rerun with -incremental to continue)", errMaxRuntime, a.MaxRuntime, len(pending))
+}
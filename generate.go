@@ -0,0 +1,491 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// writeError marks a failure to write a generated file, as opposed to a
+// failure to generate it.
+type writeError struct {
+	error
+}
+
+// errMaxRuntime stops a run that exceeded MaxRuntime before its next file.
+var errMaxRuntime = errors.New("maximum runtime exceeded")
+
+// generationRun holds the state of one GenerateCode call that is shared
+// between files generated concurrently.
+type generationRun struct {
+	agent      *DevAgent
+	spec       *ProjectSpec
+	projectDir string
+	// inMemory keeps the generated files in files without writing them
+	inMemory bool
+	// deadline is when no further files are started, if set
+	deadline time.Time
+
+	// baseContext holds read-only files, such as the surrounding codebase,
+	// included in the context of every generated file
+	baseContext map[string]string
+
+	mu           sync.Mutex
+	files        map[string]string // content of generated files, used as context
+	order        []string          // files stored by this run, oldest first
+	manifest     *Manifest
+	written      []string
+	failed       []string // files given up on under KeepGoing
+	emptyRetried []string // files regenerated because they came back empty
+
+	// embeddings of the spec's file descriptions, set when context is
+	// selected by similarity
+	embeddings map[string][]float32
+}
+
+// context returns a snapshot of the base context and the files generated
+// so far; generated files replace base files of the same path. Files the
+// spec excludes from context are left out.
+func (r *generationRun) context() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	context := make(map[string]string, len(r.baseContext)+len(r.files))
+	for filePath, content := range r.baseContext {
+		context[filePath] = content
+	}
+	for filePath, content := range r.files {
+		context[filePath] = content
+	}
+	for _, filePath := range r.spec.ExcludeFromContext {
+		delete(context, filePath)
+	}
+	return context
+}
+
+// contextFor returns the files to include as context for filePath. With
+// embeddings only the ContextTopK generated files whose descriptions are
+// most similar are included, and with a ContextWindow only the most
+// recently generated ones, besides the whole base context and the files
+// filePath depends on.
+func (r *generationRun) contextFor(filePath string) map[string]string {
+	context := r.context()
+	if r.embeddings == nil && r.agent.ContextWindow > 0 {
+		return r.withDependencies(filePath, context, r.recentContext(context, r.agent.ContextWindow))
+	}
+	if r.embeddings == nil {
+		return context
+	}
+	candidates := make([]string, 0, len(context))
+	for candidate := range context {
+		if _, planned := r.spec.Files[candidate]; planned {
+			candidates = append(candidates, candidate)
+		}
+	}
+	sort.Strings(candidates)
+
+	selected := make(map[string]string)
+	for basePath, content := range r.baseContext {
+		if _, planned := r.spec.Files[basePath]; !planned {
+			selected[basePath] = content
+		}
+	}
+	for _, candidate := range mostSimilar(r.embeddings, filePath, candidates, r.agent.ContextTopK) {
+		selected[candidate] = context[candidate]
+	}
+	return r.withDependencies(filePath, context, selected)
+}
+
+// withDependencies adds the files of context that filePath depends on to
+// selected.
+func (r *generationRun) withDependencies(filePath string, context, selected map[string]string) map[string]string {
+	for _, dep := range r.spec.dependencies[filePath] {
+		if content, ok := context[dep]; ok {
+			selected[dep] = content
+		}
+	}
+	return selected
+}
+
+// recentContext returns the base context and the last n files of context
+// that the spec plans. Files reused from disk count as older than the ones
+// generated in this run.
+func (r *generationRun) recentContext(context map[string]string, n int) map[string]string {
+	r.mu.Lock()
+	stored := make(map[string]bool, len(r.order))
+	for _, filePath := range r.order {
+		stored[filePath] = true
+	}
+	var recent []string
+	for filePath := range r.files {
+		if _, ok := context[filePath]; ok && !stored[filePath] {
+			recent = append(recent, filePath)
+		}
+	}
+	sort.Strings(recent)
+	for _, filePath := range r.order {
+		if _, ok := context[filePath]; ok {
+			recent = append(recent, filePath)
+		}
+	}
+	r.mu.Unlock()
+
+	selected := make(map[string]string)
+	for basePath, content := range r.baseContext {
+		if _, planned := r.spec.Files[basePath]; !planned {
+			selected[basePath] = content
+		}
+	}
+	if len(recent) > n {
+		recent = recent[len(recent)-n:]
+	}
+	for _, filePath := range recent {
+		selected[filePath] = context[filePath]
+	}
+	return selected
+}
+
+// store keeps generated content as context for later files.
+func (r *generationRun) store(filePath, content string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files[filePath] = content
+	if i := indexOf(r.order, filePath); i >= 0 {
+		r.order = append(r.order[:i], r.order[i+1:]...)
+	}
+	r.order = append(r.order, filePath)
+}
+
+// write writes a generated file and records it in the manifest. Under
+// KeepGoing a failed write is reported and skipped; otherwise it is
+// returned as a writeError.
+func (r *generationRun) write(filePath, kind, content string, update func(entry *ManifestEntry)) error {
+	var err error
+	if !r.inMemory {
+		err = writeProjectFile(r.projectDir, filePath, content)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		if !r.agent.KeepGoing {
+			return writeError{err}
+		}
+		r.agent.fileFailed(filePath, err)
+		r.failed = append(r.failed, filePath)
+		return nil
+	}
+	r.written = append(r.written, filePath)
+	entry := r.manifest.Add(filePath, kind)
+	entry.Provider = r.agent.ProviderFor(filePath)
+	if update != nil {
+		update(entry)
+	}
+	return nil
+}
+
+// generate generates, writes and optionally tests one file of the spec.
+func (r *generationRun) generate(filePath string) error {
+	return r.generateStarted(filePath, r.agent.startFile(filePath))
+}
+
+// generateStarted is generate for a file whose start was already reported;
+// done reports it generated.
+func (r *generationRun) generateStarted(filePath string, done func()) error {
+	generateSource := r.sourceGenerator(filePath)
+	fileContent, err := generateSource()
+	if err != nil {
+		return err
+	}
+	return r.finish(filePath, fileContent, generateSource, done)
+}
+
+// sourceGenerator returns a function generating filePath on its own.
+func (r *generationRun) sourceGenerator(filePath string) func() (string, error) {
+	return func() (string, error) {
+		return r.generateNonEmpty(filePath, func() (string, error) {
+			return r.agent.GenerateFile(r.spec, filePath, r.contextFor(filePath))
+		})
+	}
+}
+
+// finish finalizes, writes and optionally tests generated content of
+// filePath. generateSource regenerates it on review.
+func (r *generationRun) finish(filePath, fileContent string, generateSource func() (string, error), done func()) error {
+	a := r.agent
+	fileContent = a.finalizeContent(filePath, r.keepManualEdits(filePath, fileContent))
+	fileContent, ok, err := r.approve(filePath, fileContent, generateSource)
+	if err != nil || !ok {
+		return err
+	}
+
+	// Store generated content for context in subsequent generations
+	r.store(filePath, fileContent)
+
+	hash := descriptionHash(r.spec.Files[filePath])
+	written := false
+	err = r.write(filePath, fileKindSource, fileContent, func(entry *ManifestEntry) {
+		entry.DescriptionHash = hash
+		written = true
+	})
+	if err != nil || !written {
+		return err
+	}
+	done()
+	a.previewFile(filePath, fileContent)
+
+	if !a.WithTests {
+		return nil
+	}
+	testPath, ok := testFilePath(filePath)
+	if _, planned := r.spec.Files[testPath]; !ok || planned {
+		return nil
+	}
+	if r.expired() {
+		return fmt.Errorf("%w before generating %s", errMaxRuntime, testPath)
+	}
+
+	done = a.startFile(testPath)
+	generateTest := func() (string, error) {
+		return r.generateNonEmpty(testPath, func() (string, error) {
+			return a.GenerateTestFile(r.spec, filePath, testPath, r.contextFor(filePath))
+		})
+	}
+	testContent, err := generateTest()
+	if err != nil {
+		return err
+	}
+	testContent = a.finalizeContent(testPath, r.keepManualEdits(testPath, testContent))
+	testContent, ok, err = r.approve(testPath, testContent, generateTest)
+	if err != nil || !ok {
+		return err
+	}
+	r.store(testPath, testContent)
+
+	written = false
+	err = r.write(testPath, fileKindTest, testContent, func(entry *ManifestEntry) {
+		entry.TestFor = filePath
+		written = true
+	})
+	if err != nil || !written {
+		return err
+	}
+	done()
+	a.previewFile(testPath, testContent)
+	return nil
+}
+
+// generateNonEmpty calls generate and, if the content is empty or only
+// whitespace, calls it once more. Under Strict an empty file fails at once.
+func (r *generationRun) generateNonEmpty(filePath string, generate func() (string, error)) (string, error) {
+	content, err := generate()
+	if err != nil || strings.TrimSpace(content) != "" {
+		return content, err
+	}
+	if r.agent.Strict {
+		return "", fmt.Errorf("%s was generated empty", filePath)
+	}
+
+	r.agent.Log.Warnf("%s was generated empty, retrying", filePath)
+	r.mu.Lock()
+	r.emptyRetried = append(r.emptyRetried, filePath)
+	r.mu.Unlock()
+	content, err = generate()
+	if err == nil && strings.TrimSpace(content) == "" {
+		return "", fmt.Errorf("%s was generated empty twice", filePath)
+	}
+	return content, err
+}
+
+// expired reports whether the run is past its deadline.
+func (r *generationRun) expired() bool {
+	return !r.deadline.IsZero() && time.Now().After(r.deadline)
+}
+
+// generateFiles generates the given files in order, running up to
+// Concurrency of them at a time with at most MaxConcurrencyPerDir in the
+// same directory. A file is not started before the files it depends on are
+// finished. With BatchFiles, small files that are ready together share one
+// request, which takes one place of Concurrency. After the first error, or
+// once the deadline passed, no further files are started; files in
+// progress are finished. Under KeepGoing a failed file is recorded and the
+// others are still generated.
+func (r *generationRun) generateFiles(filePaths []string) error {
+	concurrency := r.agent.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	perDir := r.agent.MaxConcurrencyPerDir
+
+	// Each request sends one result per file, the last of them with last
+	// set
+	type result struct {
+		filePath string
+		err      error
+		last     bool
+	}
+	results := make(chan result)
+	pending := append([]string(nil), filePaths...)
+	running := 0
+	runningIn := make(map[string]int)
+	// Files wait only for dependencies ordered before them, so that a
+	// dependency cycle cannot stall the run
+	position := make(map[string]int, len(filePaths))
+	unfinished := make(map[string]bool, len(filePaths))
+	for i, filePath := range filePaths {
+		position[filePath] = i
+		unfinished[filePath] = true
+	}
+	ready := func(filePath string) bool {
+		if perDir > 0 && runningIn[filepath.Dir(filePath)] >= perDir {
+			return false
+		}
+		for _, dep := range r.spec.dependencies[filePath] {
+			if unfinished[dep] && position[dep] < position[filePath] {
+				return false
+			}
+		}
+		return true
+	}
+
+	var firstErr error
+	for {
+		// Start the next files whose directory has room, in order
+		for firstErr == nil && running < concurrency {
+			if len(pending) > 0 && r.expired() {
+				firstErr = errMaxRuntime
+				break
+			}
+			next := -1
+			for i, filePath := range pending {
+				if ready(filePath) {
+					next = i
+					break
+				}
+			}
+			if next < 0 {
+				break
+			}
+			filePath := pending[next]
+			pending = append(pending[:next], pending[next+1:]...)
+			running++
+			runningIn[filepath.Dir(filePath)]++
+			if !r.batchable(filePath) {
+				go func() {
+					results <- result{filePath, r.generate(filePath), true}
+				}()
+				continue
+			}
+
+			batch := []string{filePath}
+			for i := 0; i < len(pending) && len(batch) < r.agent.BatchFiles; {
+				if !r.batchableWith(filePath, pending[i]) || !ready(pending[i]) {
+					i++
+					continue
+				}
+				batch = append(batch, pending[i])
+				runningIn[filepath.Dir(pending[i])]++
+				pending = append(pending[:i], pending[i+1:]...)
+			}
+			go func() {
+				if len(batch) == 1 {
+					results <- result{filePath, r.generate(filePath), true}
+					return
+				}
+				errs := r.generateBatch(batch)
+				for i, batched := range batch {
+					results <- result{batched, errs[batched], i == len(batch)-1}
+				}
+			}()
+		}
+
+		if running == 0 {
+			return firstErr
+		}
+		res := <-results
+		if res.last {
+			running--
+		}
+		runningIn[filepath.Dir(res.filePath)]--
+		delete(unfinished, res.filePath)
+		if res.err != nil && r.agent.KeepGoing && r.agent.ctx.Err() == nil && !errors.Is(res.err, errMaxRuntime) {
+			r.agent.fileFailed(res.filePath, res.err)
+			r.mu.Lock()
+			r.failed = append(r.failed, res.filePath)
+			r.mu.Unlock()
+			continue
+		}
+		if res.err != nil && firstErr == nil {
+			firstErr = res.err
+		}
+	}
+}
+
+// generationOrder returns the spec's files in the order they are
+// generated: the files listed in Order first, then the others sorted by
+// path, with each file moved after the files it depends on.
+func generationOrder(spec *ProjectSpec) []string {
+	var filePaths []string
+	listed := make(map[string]bool, len(spec.Order))
+	for _, filePath := range spec.Order {
+		if _, ok := spec.Files[filePath]; ok && !listed[filePath] {
+			listed[filePath] = true
+			filePaths = append(filePaths, filePath)
+		}
+	}
+
+	var rest []string
+	for filePath := range spec.Files {
+		if !listed[filePath] {
+			rest = append(rest, filePath)
+		}
+	}
+	sort.Strings(rest)
+	return orderByDependencies(append(filePaths, rest...), spec.dependencies)
+}
+
+// indexOf returns the position of value in values, or -1.
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// notWritten returns the files of filePaths that were not written.
+func (r *generationRun) notWritten(filePaths []string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	done := make(map[string]bool, len(r.written))
+	for _, filePath := range r.written {
+		done[filePath] = true
+	}
+	var pending []string
+	for _, filePath := range filePaths {
+		if !done[filePath] {
+			pending = append(pending, filePath)
+		}
+	}
+	return pending
+}
+
+// PartialError is returned by a KeepGoing run that generated the project
+// except for the files that failed.
+type PartialError struct {
+	Files []string
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("failed to generate or write %d file(s): %s", len(e.Files), strings.Join(e.Files, ", "))
+}
+
+// isWriteError reports whether err is a failure to write a generated file.
+func isWriteError(err error) bool {
+	var we writeError
+	return errors.As(err, &we)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// git runs a git command in dir and returns its trimmed output. Failures
+// include git's own message.
+func (a *DevAgent) git(dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(a.ctx, "git", args...)
+	cmd.Dir = dir
+	var out, errOut bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(errOut.String()); message != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return "", fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// existingParent returns dir or its closest ancestor that exists.
+func existingParent(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "."
+	}
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// generateOnBranch checks out GitBranch, creating it if needed, in the git
+// repository that holds the project directory, generates the project there
+// and, with GitCommit, commits the generated files to the branch. With
+// Changelog an entry describing the generated files is added to the
+// repository's CHANGELOG.md first.
+func (a *DevAgent) generateOnBranch(spec *ProjectSpec) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return errors.New("cannot create a git branch: git is not installed or not on the PATH")
+	}
+
+	projectDir := a.ProjectDir(spec)
+	repoDir, err := a.git(existingParent(projectDir), "rev-parse", "--show-toplevel")
+	if err != nil {
+		return fmt.Errorf("cannot create a git branch: %s is not inside a git repository", projectDir)
+	}
+	if err := a.checkoutBranch(repoDir); err != nil {
+		return err
+	}
+
+	if err := a.generateCode(spec, projectDir); err != nil {
+		return err
+	}
+	// git runs in repoDir, so a relative path would be resolved against it
+	absProjectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return err
+	}
+	paths := []string{absProjectDir}
+	if a.Changelog {
+		changelog, err := a.UpdateChangelog(spec, repoDir, projectDir)
+		if err != nil {
+			return err
+		}
+		if changelog != "" {
+			paths = append(paths, filepath.Join(repoDir, changelog))
+		}
+	}
+	if !a.GitCommit {
+		return nil
+	}
+
+	if _, err := a.git(repoDir, append([]string{"add", "-A", "--"}, paths...)...); err != nil {
+		return fmt.Errorf("failed to stage generated files: %v", err)
+	}
+	if staged, err := a.git(repoDir, "diff", "--cached", "--name-only"); err != nil || staged == "" {
+		a.Log.Infof("⏭️  Nothing to commit on branch %s", a.GitBranch)
+		return err
+	}
+	message := fmt.Sprintf("Generate %s\n\n%s", spec.Name, spec.Description)
+	if _, err := a.git(repoDir, "commit", "-q", "-m", message); err != nil {
+		return fmt.Errorf("failed to commit generated files: %v", err)
+	}
+	a.Log.Infof("📝 Committed the generated files to branch %s", a.GitBranch)
+	return nil
+}
+
+// checkoutBranch switches the repository at repoDir to GitBranch, creating
+// it from the current commit if it does not exist yet. Staying on the
+// branch, as a later generation in the REPL or with -watch does, is fine
+// with uncommitted changes; switching to it needs a clean working tree.
+func (a *DevAgent) checkoutBranch(repoDir string) error {
+	if current, err := a.git(repoDir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil && current == a.GitBranch {
+		a.Log.Infof("🌿 Generating on branch %s", a.GitBranch)
+		return nil
+	}
+	status, err := a.git(repoDir, "status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	if status != "" {
+		return fmt.Errorf("cannot switch to branch %s: the working tree of %s has uncommitted changes; commit or stash them first", a.GitBranch, repoDir)
+	}
+	if _, err := a.git(repoDir, "rev-parse", "--verify", "--quiet", "refs/heads/"+a.GitBranch); err == nil {
+		if _, err := a.git(repoDir, "checkout", a.GitBranch); err != nil {
+			return fmt.Errorf("failed to check out branch %s: %v", a.GitBranch, err)
+		}
+		a.Log.Infof("🌿 Generating on existing branch %s", a.GitBranch)
+		return nil
+	}
+	if _, err := a.git(repoDir, "checkout", "-b", a.GitBranch); err != nil {
+		return fmt.Errorf("failed to create branch %s: %v", a.GitBranch, err)
+	}
+	a.Log.Infof("🌿 Generating on new branch %s", a.GitBranch)
+	return nil
+}
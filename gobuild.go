@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// goCompileError matches a compiler error line such as
+// "handlers/user.go:12:3: undefined: models.User".
+var goCompileError = regexp.MustCompile(`(?m)^(?:\./)?([^\s:]+\.go):\d+(?::\d+)?: .+$`)
+
+// isGoProject reports whether any of the files is Go source.
+func isGoProject(files map[string]string) bool {
+	for filePath := range files {
+		if strings.HasSuffix(filePath, ".go") {
+			return true
+		}
+	}
+	return false
+}
+
+// runGo runs a go command in dir and returns its combined output.
+func (a *DevAgent) runGo(dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(a.ctx, "go", args...)
+	cmd.Dir = dir
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return out.String(), err
+}
+
+// goBuildErrors groups the compiler errors in go build output by file.
+func goBuildErrors(output string) map[string][]string {
+	errs := make(map[string][]string)
+	for _, m := range goCompileError.FindAllStringSubmatch(output, -1) {
+		filePath := filepath.ToSlash(filepath.Clean(m[1]))
+		errs[filePath] = append(errs[filePath], strings.TrimPrefix(m[0], "./"))
+	}
+	return errs
+}
+
+// CheckGoBuild runs go build in a generated Go project and reports the
+// compile errors. Files that fail to compile are sent back to the model
+// together with their errors, up to FixAttempts rounds; files in keep are
+// never changed. No fix round starts after deadline, unless it is zero.
+// Build failures are reported, not returned; only problems running the
+// toolchain and a passed deadline are errors.
+func (a *DevAgent) CheckGoBuild(spec *ProjectSpec, projectDir string, files map[string]string, keep map[string]bool, deadline time.Time) error {
+	if !isGoProject(files) {
+		return nil
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		a.Log.Warnf("Skipping go build check: the go command was not found")
+		return nil
+	}
+
+	a.Log.Infof("🔨 Running go build...")
+	if _, ok := files["go.mod"]; ok {
+		if out, err := a.runGo(projectDir, "mod", "tidy"); err != nil {
+			a.Log.Warnf("go mod tidy failed:\n%s", strings.TrimSpace(out))
+		}
+	}
+
+	for round := 0; ; round++ {
+		out, err := a.runGo(projectDir, "build", "./...")
+		if err == nil {
+			a.Log.Infof("✅ go build succeeded")
+			return nil
+		}
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf("failed to run go build: %v", err)
+		}
+
+		errs := goBuildErrors(out)
+		if len(errs) == 0 {
+			a.Log.Errorf("go build failed:\n%s", strings.TrimSpace(out))
+			return nil
+		}
+		if round >= a.FixAttempts {
+			a.reportGoBuildErrors(errs)
+			return nil
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			a.reportGoBuildErrors(errs)
+			return fmt.Errorf("%v after %s: stopped before fixing the compile errors", errMaxRuntime, a.MaxRuntime)
+		}
+
+		temperature := a.fixTemperature(round + 1)
+		a.Log.Infof("🔧 Fixing compile errors in %d file(s) (fix attempt %d/%d, temperature %.2f)...", len(errs), round+1, a.FixAttempts, temperature)
+		var paths []string
+		for filePath := range errs {
+			paths = append(paths, filePath)
+		}
+		sort.Strings(paths)
+		for _, filePath := range paths {
+			if _, ok := files[filePath]; !ok || keep[filePath] {
+				continue
+			}
+			fixed, err := a.fixGoFile(spec, filePath, errs[filePath], files, temperature)
+			if err != nil {
+				return err
+			}
+			fixed = a.finalizeContent(filePath, fixed)
+			files[filePath] = fixed
+			if err := writeProjectFile(projectDir, filePath, fixed); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// reportGoBuildErrors summarizes the remaining compile errors.
+func (a *DevAgent) reportGoBuildErrors(errs map[string][]string) {
+	var paths []string
+	count := 0
+	for filePath, fileErrs := range errs {
+		paths = append(paths, filePath)
+		count += len(fileErrs)
+	}
+	sort.Strings(paths)
+
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("go build failed with %d error(s) in %d file(s):", count, len(paths)))
+	for _, filePath := range paths {
+		for _, e := range errs[filePath] {
+			summary.WriteString("\n   " + e)
+		}
+	}
+	a.Log.Errorf("%s", summary.String())
+}
+
+// fixGoFile asks the model to correct a Go file given its compile errors
+// and the other Go files of the project.
+func (a *DevAgent) fixGoFile(spec *ProjectSpec, filePath string, errs []string, files map[string]string, temperature float32) (string, error) {
+	var contextBuilder strings.Builder
+	for otherPath, content := range files {
+		if otherPath != filePath && (strings.HasSuffix(otherPath, ".go") || otherPath == "go.mod") {
+			contextBuilder.WriteString(fmt.Sprintf("\n%s:\n```\n%s\n```\n", otherPath, content))
+		}
+	}
+
+	fixPrompt := fmt.Sprintf(`The file %s in the %s project does not compile.
+Project Description: %s
+File Purpose: %s
+
+Compiler errors:
+%s
+
+Current content of %s:
+`+"```"+`
+%s
+`+"```"+`
+
+Other project files:
+%s
+Fix the compile errors while keeping the file's behavior. Make it consistent with the types and functions the other files actually define.
+Generate only the complete corrected file, no explanations.`, filePath, spec.Name, spec.Description, spec.Files[filePath], strings.Join(errs, "\n"), filePath, files[filePath], contextBuilder.String())
+
+	content, err := a.chat(chatRequest{
+		Label:       filePath,
+		Step:        "build fix",
+		Model:       a.codeModelFor(filePath),
+		System:      a.codeSystemPromptFor(filePath),
+		Prompt:      a.wrapCodePrompt(fixPrompt),
+		Temperature: temperature,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to fix %s: %v", filePath, err)
+	}
+	return stripCodeFences(filePath, content), nil
+}
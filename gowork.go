@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// goWorkName is the workspace file written to the project root.
+const goWorkName = "go.work"
+
+// defaultGoVersion is used in go.work when no module declares a version.
+const defaultGoVersion = "1.21"
+
+// modulePathElement matches one element of a valid module path.
+var modulePathElement = regexp.MustCompile(`^[A-Za-z0-9_~-][A-Za-z0-9._~-]*$`)
+
+// goVersionLine matches the go directive of a go.mod file.
+var goVersionLine = regexp.MustCompile(`(?m)^go\s+(\d+\.\d+(?:\.\d+)?)\s*$`)
+
+// validateModulePath checks that p is a usable Go module path.
+func validateModulePath(p string) error {
+	if p == "" {
+		return fmt.Errorf("module path is empty")
+	}
+	for _, element := range strings.Split(p, "/") {
+		if !modulePathElement.MatchString(element) || strings.HasSuffix(element, ".") {
+			return fmt.Errorf("invalid module path %q: element %q may only contain letters, digits and -._~ and must not start or end with a dot", p, element)
+		}
+	}
+	return nil
+}
+
+// goModules returns the directories of the Go modules the spec plans, as
+// given by its go.mod files, sorted; the root module is ".". It returns
+// nil for a single root module, which needs no workspace.
+func goModules(spec *ProjectSpec) []string {
+	var dirs []string
+	for filePath := range spec.Files {
+		if path.Base(filePath) == "go.mod" {
+			dirs = append(dirs, path.Dir(filePath))
+		}
+	}
+	if len(dirs) == 0 || (len(dirs) == 1 && dirs[0] == ".") {
+		return nil
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// modulePath returns the module path of the module in dir.
+func (a *DevAgent) modulePath(spec *ProjectSpec, dir string) string {
+	base := a.ImportPath(spec)
+	if base == "" {
+		base = spec.Name
+	}
+	if dir == "." {
+		return base
+	}
+	return path.Join(base, dir)
+}
+
+// validateWorkspace checks the module paths of the Go workspace the spec
+// plans, so invalid paths fail before anything is generated.
+func (a *DevAgent) validateWorkspace(spec *ProjectSpec) error {
+	if !a.GoWorkspace {
+		return nil
+	}
+	for _, dir := range goModules(spec) {
+		if err := validateModulePath(a.modulePath(spec, dir)); err != nil {
+			return fmt.Errorf("cannot generate the Go workspace: %v", err)
+		}
+	}
+	return nil
+}
+
+// moduleFor returns the directory of the module that holds filePath: the
+// module with the longest matching directory.
+func moduleFor(modules []string, filePath string) (string, bool) {
+	best, found := "", false
+	for _, dir := range modules {
+		if (dir == "." || strings.HasPrefix(filePath, dir+"/")) && (!found || len(dir) > len(best)) {
+			best, found = dir, true
+		}
+	}
+	return best, found
+}
+
+// workspaceInstruction returns prompt requirement lines naming the module
+// paths of a Go workspace, or an empty string if the spec plans none.
+func (a *DevAgent) workspaceInstruction(spec *ProjectSpec) string {
+	modules := goModules(spec)
+	if !a.GoWorkspace || modules == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("- The project is a Go workspace; each module imports its own packages and those of the other modules by module path:\n")
+	for _, dir := range modules {
+		fmt.Fprintf(&b, "  - %s/ is the module %s\n", dir, a.modulePath(spec, dir))
+	}
+	return b.String()
+}
+
+// planGoModules plans one go.mod per module of a Go workspace, listing
+// the packages the module's files import. The workspace's own modules are
+// left out, as go.work resolves them.
+func (a *DevAgent) planGoModules(spec *ProjectSpec, modules []string, generatedFiles map[string]string) ([]dependencyManifestPlan, error) {
+	modulePaths := make(map[string]string, len(modules))
+	for _, dir := range modules {
+		modulePaths[dir] = a.modulePath(spec, dir)
+		if err := validateModulePath(modulePaths[dir]); err != nil {
+			return nil, err
+		}
+	}
+
+	imports := make(map[string]map[string]bool, len(modules))
+	for filePath, content := range generatedFiles {
+		dir, ok := moduleFor(modules, filePath)
+		if !ok || languageForFile(filePath) != "go" {
+			continue
+		}
+		if imports[dir] == nil {
+			imports[dir] = make(map[string]bool)
+		}
+		for _, imp := range scanImports("go", content) {
+			local := false
+			for _, modulePath := range modulePaths {
+				if imp == modulePath || strings.HasPrefix(imp, modulePath+"/") {
+					local = true
+					break
+				}
+			}
+			if !local {
+				imports[dir][imp] = true
+			}
+		}
+	}
+
+	plans := make([]dependencyManifestPlan, 0, len(modules))
+	for _, dir := range modules {
+		plan := dependencyManifestPlan{Path: path.Join(dir, "go.mod"), Name: "go.mod", Module: modulePaths[dir]}
+		for imp := range imports[dir] {
+			plan.Packages = append(plan.Packages, imp)
+		}
+		sort.Strings(plan.Packages)
+		plan.Existing = generatedFiles[plan.Path]
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// goWork renders a go.work that uses every module, with the highest Go
+// version their go.mod files declare.
+func goWork(modules []string, generatedFiles map[string]string) string {
+	version := defaultGoVersion
+	for _, dir := range modules {
+		if m := goVersionLine.FindStringSubmatch(generatedFiles[path.Join(dir, "go.mod")]); m != nil && compareGoVersions(m[1], version) > 0 {
+			version = m[1]
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "go %s\n\nuse (\n", version)
+	for _, dir := range modules {
+		if dir == "." {
+			b.WriteString("\t.\n")
+		} else {
+			fmt.Fprintf(&b, "\t./%s\n", dir)
+		}
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// compareGoVersions compares two Go versions such as 1.21 and 1.22.3.
+func compareGoVersions(x, y string) int {
+	xs, ys := strings.Split(x, "."), strings.Split(y, ".")
+	for i := 0; i < len(xs) || i < len(ys); i++ {
+		var xn, yn int
+		if i < len(xs) {
+			fmt.Sscan(xs[i], &xn)
+		}
+		if i < len(ys) {
+			fmt.Sscan(ys[i], &yn)
+		}
+		if xn != yn {
+			if xn < yn {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// commentStyle describes how a language writes comments. Languages without
+// line comments use Start and End around the whole header, with Line
+// prefixing the lines between.
+type commentStyle struct {
+	Start string
+	Line  string
+	End   string
+}
+
+var (
+	slashComments = commentStyle{Line: "// "}
+	hashComments  = commentStyle{Line: "# "}
+	dashComments  = commentStyle{Line: "-- "}
+	blockComments = commentStyle{Start: "/*", Line: " * ", End: " */"}
+	htmlComments  = commentStyle{Start: "<!--", Line: "  ", End: "-->"}
+)
+
+// commentStyles maps file extensions to their comment syntax. Formats
+// without comments, such as JSON, are absent.
+var commentStyles = map[string]commentStyle{
+	".go": slashComments, ".js": slashComments, ".jsx": slashComments, ".mjs": slashComments, ".cjs": slashComments,
+	".ts": slashComments, ".tsx": slashComments, ".java": slashComments, ".kt": slashComments, ".kts": slashComments,
+	".c": slashComments, ".h": slashComments, ".cc": slashComments, ".cpp": slashComments, ".hpp": slashComments,
+	".cs": slashComments, ".swift": slashComments, ".rs": slashComments, ".scala": slashComments, ".dart": slashComments,
+	".php": slashComments, ".scss": slashComments, ".less": slashComments, ".proto": slashComments, ".groovy": slashComments,
+
+	".py": hashComments, ".rb": hashComments, ".sh": hashComments, ".bash": hashComments, ".zsh": hashComments,
+	".pl": hashComments, ".r": hashComments, ".ps1": hashComments, ".yaml": hashComments, ".yml": hashComments,
+	".toml": hashComments, ".tf": hashComments, ".ex": hashComments, ".exs": hashComments, ".properties": hashComments,
+	".dockerfile": hashComments,
+
+	".sql": dashComments, ".lua": dashComments, ".hs": dashComments,
+
+	".css": blockComments,
+
+	".html": htmlComments, ".htm": htmlComments, ".xml": htmlComments, ".svg": htmlComments, ".vue": htmlComments,
+	".svelte": htmlComments, ".md": htmlComments,
+}
+
+// commentStyleNames maps files known by name rather than extension to
+// their comment syntax.
+var commentStyleNames = map[string]commentStyle{
+	"Dockerfile": hashComments, "Makefile": hashComments, "makefile": hashComments, "GNUmakefile": hashComments,
+	"Gemfile": hashComments, "Rakefile": hashComments, "Procfile": hashComments, ".gitignore": hashComments,
+	".dockerignore": hashComments, ".env": hashComments, ".env.example": hashComments,
+}
+
+// commentStyleFor returns the comment syntax of filePath, if it has one.
+func commentStyleFor(filePath string) (commentStyle, bool) {
+	if style, ok := commentStyleNames[filepath.Base(filePath)]; ok {
+		return style, true
+	}
+	style, ok := commentStyles[strings.ToLower(filepath.Ext(filePath))]
+	return style, ok
+}
+
+// formatHeader renders header text as a comment in the syntax of
+// filePath. It returns false for files that cannot hold comments.
+func formatHeader(filePath, header string) (string, bool) {
+	style, ok := commentStyleFor(filePath)
+	if !ok {
+		return "", false
+	}
+	var b strings.Builder
+	if style.Start != "" {
+		b.WriteString(style.Start + "\n")
+	}
+	for _, line := range strings.Split(strings.TrimRight(header, "\n"), "\n") {
+		b.WriteString(strings.TrimRight(style.Line+line, " ") + "\n")
+	}
+	if style.End != "" {
+		b.WriteString(style.End + "\n")
+	}
+	return b.String(), true
+}
+
+// headerPreamble reports whether a first line of filePath must stay first:
+// shebangs, XML and PHP openings, HTML doctypes, Python encoding
+// declarations and Dockerfile parser directives.
+func headerPreamble(filePath, line string) bool {
+	trimmed := strings.ToLower(strings.TrimSpace(line))
+	for _, prefix := range []string{"#!", "<?xml", "<?php", "<!doctype"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	if isDockerfile(filePath) {
+		return dockerParserDirective(trimmed)
+	}
+	return strings.HasPrefix(trimmed, "#") && strings.Contains(trimmed, "coding")
+}
+
+// dockerParserDirective reports whether a lowercased line is a Dockerfile
+// parser directive, e.g. "# syntax=docker/dockerfile:1", which only works
+// above every other comment.
+func dockerParserDirective(line string) bool {
+	rest, ok := strings.CutPrefix(line, "#")
+	if !ok {
+		return false
+	}
+	name, _, ok := strings.Cut(rest, "=")
+	name = strings.TrimSpace(name)
+	return ok && (name == "syntax" || name == "escape" || name == "check")
+}
+
+// prependHeader adds header, as a comment in the syntax of filePath, to
+// the top of content. Lines that must come first, such as a shebang, stay
+// above it. Content that cannot hold comments, or already starts with the
+// header, is returned unchanged.
+func prependHeader(filePath, header, content string) string {
+	comment, ok := formatHeader(filePath, header)
+	if !ok || strings.TrimSpace(header) == "" {
+		return content
+	}
+
+	var preamble string
+	rest := content
+	for i := 0; i < 3; i++ {
+		line, after, found := strings.Cut(rest, "\n")
+		if !found || !headerPreamble(filePath, line) {
+			break
+		}
+		preamble += line + "\n"
+		rest = after
+	}
+	if strings.HasPrefix(strings.TrimLeft(rest, "\n"), comment) {
+		return content
+	}
+	return preamble + comment + "\n" + rest
+}
+
+// loadHeader reads the header text added to generated files.
+func loadHeader(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", describeFSError("read header file", path, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func TestPrependHeader(t *testing.T) {
+	const header = "Copyright 2024 Example\nSPDX-License-Identifier: MIT"
+	tests := []struct {
+		name     string
+		filePath string
+		content  string
+		want     string
+	}{
+		{
+			"go", "main.go",
+			"package main\n",
+			"// Copyright 2024 Example\n// SPDX-License-Identifier: MIT\n\npackage main\n",
+		},
+		{
+			"python", "app.py",
+			"import os\n",
+			"# Copyright 2024 Example\n# SPDX-License-Identifier: MIT\n\nimport os\n",
+		},
+		{
+			"python encoding", "app.py",
+			"#!/usr/bin/env python3\n# -*- coding: utf-8 -*-\nimport os\n",
+			"#!/usr/bin/env python3\n# -*- coding: utf-8 -*-\n# Copyright 2024 Example\n# SPDX-License-Identifier: MIT\n\nimport os\n",
+		},
+		{
+			"html", "index.html",
+			"<!DOCTYPE html>\n<html></html>\n",
+			"<!DOCTYPE html>\n<!--\n  Copyright 2024 Example\n  SPDX-License-Identifier: MIT\n-->\n\n<html></html>\n",
+		},
+		{
+			"shell", "run.sh",
+			"#!/bin/sh\necho hi\n",
+			"#!/bin/sh\n# Copyright 2024 Example\n# SPDX-License-Identifier: MIT\n\necho hi\n",
+		},
+		{
+			"dockerfile directives", "Dockerfile",
+			"# syntax=docker/dockerfile:1\n# escape=`\nFROM golang:1.22\n",
+			"# syntax=docker/dockerfile:1\n# escape=`\n# Copyright 2024 Example\n# SPDX-License-Identifier: MIT\n\nFROM golang:1.22\n",
+		},
+		{
+			"dockerfile", "Dockerfile",
+			"# build stage\nFROM golang:1.22\n",
+			"# Copyright 2024 Example\n# SPDX-License-Identifier: MIT\n\n# build stage\nFROM golang:1.22\n",
+		},
+		{
+			"already present", "main.go",
+			"// Copyright 2024 Example\n// SPDX-License-Identifier: MIT\n\npackage main\n",
+			"// Copyright 2024 Example\n// SPDX-License-Identifier: MIT\n\npackage main\n",
+		},
+		{
+			"no comments", "package.json",
+			"{}\n",
+			"{}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prependHeader(tt.filePath, header, tt.content); got != tt.want {
+				t.Errorf("prependHeader(%q) = %q, want %q", tt.filePath, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// runPostHook runs the PostHook shell command in the project directory with
+// the project name and path in its environment, and prints its output. A
+// failing hook is only an error with PostHookFailOnError.
+func (a *DevAgent) runPostHook(spec *ProjectSpec, projectDir string) error {
+	if a.PostHook == "" {
+		return nil
+	}
+
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		absDir = projectDir
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(a.ctx, "cmd", "/C", a.PostHook)
+	} else {
+		cmd = exec.CommandContext(a.ctx, "sh", "-c", a.PostHook)
+	}
+	cmd.Dir = projectDir
+	cmd.Env = append(os.Environ(),
+		"ASHUTOSH_PROJECT_NAME="+spec.Name,
+		"ASHUTOSH_PROJECT_DIR="+absDir,
+	)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	a.Log.Infof("🪝 Running post-generation hook: %s", a.PostHook)
+	err = cmd.Run()
+	if output := strings.TrimRight(out.String(), "\n"); output != "" {
+		a.Log.Infof("%s", output)
+	}
+	if err == nil {
+		return nil
+	}
+	if a.PostHookFailOnError {
+		return fmt.Errorf("post-generation hook failed: %v", err)
+	}
+	a.Log.Warnf("Post-generation hook failed: %v", err)
+	return nil
+}
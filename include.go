@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// includeDirective starts a prompt file line that is replaced by the
+// content of another file, e.g. "@include standards/security.md".
+const includeDirective = "@include "
+
+// loadPromptFile reads a prompt file and expands its @include directives.
+// Included paths are resolved relative to the including file.
+func loadPromptFile(path string) (string, error) {
+	return expandIncludes(path, nil)
+}
+
+func expandIncludes(path string, stack []string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %v", path, err)
+	}
+	for i, included := range stack {
+		if included == absPath {
+			cycle := append(stack[i:], absPath)
+			return "", fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	stack = append(stack, absPath)
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return "", describeFSError("read prompt file", path, err)
+	}
+
+	var out strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, includeDirective) {
+			out.WriteString(line)
+			out.WriteString("\n")
+			continue
+		}
+
+		includePath := strings.TrimSpace(strings.TrimPrefix(trimmed, includeDirective))
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(absPath), includePath)
+		}
+		included, err := expandIncludes(includePath, stack)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(included)
+		if !strings.HasSuffix(included, "\n") {
+			out.WriteString("\n")
+		}
+	}
+	return strings.TrimSpace(out.String()), nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+)
+
+// GenerateCodeIsolated generates the project into a new, uniquely named
+// temporary directory and returns its path, so that concurrent or batch
+// runs never collide and the caller decides where the result ends up.
+// Import paths are still derived from OutputDir. The directory is returned
+// even when generation fails, so the caller can inspect or remove it;
+// with RemoveIsolated it is deleted before returning.
+func (a *DevAgent) GenerateCodeIsolated(spec *ProjectSpec) (string, error) {
+	dir, err := os.MkdirTemp("", "ashutosh-"+safeProjectName(spec.Name)+"-")
+	if err != nil {
+		return "", describeFSError("create temporary directory", os.TempDir(), err)
+	}
+
+	a.Log.Infof("📦 Generating into temporary directory %s", dir)
+	err = a.generateCode(spec, dir)
+
+	if a.RemoveIsolated {
+		if rmErr := os.RemoveAll(dir); rmErr != nil {
+			a.Log.Warnf("Could not remove temporary directory %s: %v", dir, rmErr)
+		} else {
+			a.Log.Infof("🧹 Removed temporary directory %s", dir)
+		}
+		return "", err
+	}
+	if err == nil {
+		a.Log.Infof("📦 Project written to %s", dir)
+	}
+	return dir, err
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// applyPatch applies the JSON patch ops to the JSON document doc.
+func applyPatch(t *testing.T, doc, ops string) (interface{}, error) {
+	t.Helper()
+	var node interface{}
+	if err := json.Unmarshal([]byte(doc), &node); err != nil {
+		t.Fatalf("invalid document %s: %v", doc, err)
+	}
+	var operations []patchOperation
+	if err := json.Unmarshal([]byte(ops), &operations); err != nil {
+		t.Fatalf("invalid patch %s: %v", ops, err)
+	}
+	for _, op := range operations {
+		var err error
+		node, err = applyPatchOperation(node, op)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+func TestParsePointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    []string
+	}{
+		{"", nil},
+		{"/", []string{""}},
+		{"/files/src~1main.go", []string{"files", "src/main.go"}},
+		{"/a~0b", []string{"a~b"}},
+		// ~01 is ~ followed by 1, not /
+		{"/~01", []string{"~1"}},
+		{"/a//b", []string{"a", "", "b"}},
+	}
+	for _, tt := range tests {
+		got, err := parsePointer(tt.pointer)
+		if err != nil {
+			t.Errorf("parsePointer(%q) failed: %v", tt.pointer, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePointer(%q) = %q, want %q", tt.pointer, got, tt.want)
+		}
+	}
+
+	if _, err := parsePointer("files"); err == nil {
+		t.Error("parsePointer(\"files\") = nil error, want an error for a pointer without a leading /")
+	}
+}
+
+func TestApplyPatchOperation(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		ops  string
+		want string
+	}{
+		{
+			name: "add to the empty key",
+			doc:  `{}`,
+			ops:  `[{"op": "add", "path": "/", "value": 1}]`,
+			want: `{"": 1}`,
+		},
+		{
+			name: "escaped slash and tilde",
+			doc:  `{"files": {"src/main.go": "old", "a~b": "x"}}`,
+			ops: `[{"op": "replace", "path": "/files/src~1main.go", "value": "new"},
+				{"op": "remove", "path": "/files/a~0b"}]`,
+			want: `{"files": {"src/main.go": "new"}}`,
+		},
+		{
+			name: "append with -",
+			doc:  `{"order": ["a"]}`,
+			ops:  `[{"op": "add", "path": "/order/-", "value": "b"}]`,
+			want: `{"order": ["a", "b"]}`,
+		},
+		{
+			name: "insert before an index",
+			doc:  `{"order": ["a", "c"]}`,
+			ops:  `[{"op": "add", "path": "/order/1", "value": "b"}]`,
+			want: `{"order": ["a", "b", "c"]}`,
+		},
+		{
+			name: "move",
+			doc:  `{"files": {"old.go": "desc"}}`,
+			ops:  `[{"op": "move", "from": "/files/old.go", "path": "/files/new.go"}]`,
+			want: `{"files": {"new.go": "desc"}}`,
+		},
+		{
+			name: "copy is independent of its source",
+			doc:  `{"a": {"x": [1]}}`,
+			ops: `[{"op": "copy", "from": "/a", "path": "/b"},
+				{"op": "add", "path": "/b/x/-", "value": 2}]`,
+			want: `{"a": {"x": [1]}, "b": {"x": [1, 2]}}`,
+		},
+		{
+			name: "passing test",
+			doc:  `{"name": "app"}`,
+			ops:  `[{"op": "test", "path": "/name", "value": "app"}]`,
+			want: `{"name": "app"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyPatch(t, tt.doc, tt.ops)
+			if err != nil {
+				t.Fatalf("patch failed: %v", err)
+			}
+			var want interface{}
+			json.Unmarshal([]byte(tt.want), &want)
+			if !reflect.DeepEqual(got, want) {
+				gotJSON, _ := json.Marshal(got)
+				t.Errorf("patched document = %s, want %s", gotJSON, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPatchOperationErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		ops  string
+	}{
+		{"failing test", `{"name": "app"}`, `[{"op": "test", "path": "/name", "value": "other"}]`},
+		{"test of a missing member", `{}`, `[{"op": "test", "path": "/name", "value": "app"}]`},
+		{"remove a missing member", `{}`, `[{"op": "remove", "path": "/name"}]`},
+		{"index past the end", `{"order": []}`, `[{"op": "add", "path": "/order/1", "value": "a"}]`},
+		{"move into itself", `{"a": {"b": 1}}`, `[{"op": "move", "from": "/a", "path": "/a/c"}]`},
+		{"unknown operation", `{}`, `[{"op": "merge", "path": "/a"}]`},
+		{"add without value", `{}`, `[{"op": "add", "path": "/a"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := applyPatch(t, tt.doc, tt.ops); err == nil {
+				t.Error("patch succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// k8sDir is where the Kubernetes manifests are written.
+const k8sDir = "k8s"
+
+// defaultK8sNamespace is the namespace of the manifests unless -k8s-namespace
+// sets another.
+const defaultK8sNamespace = "default"
+
+// k8sManifests are the manifests written to k8sDir, each with the kind of
+// object it holds, in the order they are applied.
+var k8sManifests = []struct {
+	Name string
+	Kind string
+}{
+	{"configmap.yaml", "ConfigMap"},
+	{"deployment.yaml", "Deployment"},
+	{"service.yaml", "Service"},
+}
+
+// k8sNamePattern matches valid Kubernetes object and namespace names.
+var k8sNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// k8sNameInvalid matches the runs of characters not allowed in Kubernetes
+// names.
+var k8sNameInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+
+// validateK8sNamespace checks that namespace is a valid namespace name.
+func validateK8sNamespace(namespace string) error {
+	if len(namespace) > 63 || !k8sNamePattern.MatchString(namespace) {
+		return fmt.Errorf("invalid Kubernetes namespace %q: use at most 63 lowercase letters, digits and dashes", namespace)
+	}
+	return nil
+}
+
+// k8sName turns a project name into a Kubernetes object name.
+func k8sName(name string) string {
+	name = strings.Trim(k8sNameInvalid.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	if name == "" {
+		return "app"
+	}
+	return name
+}
+
+// isDockerfile reports whether filePath is a Dockerfile.
+func isDockerfile(filePath string) bool {
+	base := filepath.Base(filePath)
+	return base == "Dockerfile" || strings.HasPrefix(base, "Dockerfile.") || strings.HasSuffix(base, ".dockerfile")
+}
+
+// GenerateK8sManifests asks the model for a Deployment, Service and
+// ConfigMap that run the generated project in the given namespace from the
+// given image, and writes them under k8s/. The Dockerfile, if the project
+// has one, and the environment variables the code reads are sent as
+// context. An empty image names it after the project. It returns the
+// written paths, or none if the project already has Kubernetes manifests.
+func (a *DevAgent) GenerateK8sManifests(spec *ProjectSpec, projectDir, namespace, image string, generatedFiles map[string]string) ([]string, error) {
+	for filePath := range generatedFiles {
+		if strings.HasPrefix(filePath, k8sDir+"/") {
+			a.Log.Infof("⏭️  Skipping Kubernetes manifests: the project already has %s", filePath)
+			return nil, nil
+		}
+	}
+	for _, manifest := range k8sManifests {
+		if a.keepExisting(projectDir, k8sDir+"/"+manifest.Name) {
+			return nil, nil
+		}
+	}
+
+	name := k8sName(spec.Name)
+	if image == "" {
+		image = name + ":latest"
+	}
+
+	var filePaths []string
+	var dockerfiles strings.Builder
+	for filePath, content := range generatedFiles {
+		filePaths = append(filePaths, filePath)
+		if isDockerfile(filePath) {
+			dockerfiles.WriteString(fmt.Sprintf("\n%s:\n```\n%s\n```\n", filePath, content))
+		}
+	}
+	sort.Strings(filePaths)
+	if dockerfiles.Len() == 0 {
+		dockerfiles.WriteString("\nThe project has no Dockerfile; assume the image runs the project with the standard command of the framework.\n")
+	}
+
+	vars := scanEnvVars(generatedFiles)
+	envNames := make([]string, 0, len(vars))
+	for envName := range vars {
+		envNames = append(envNames, envName)
+	}
+	sort.Strings(envNames)
+	environment := "none"
+	if len(envNames) > 0 {
+		environment = strings.Join(envNames, ", ")
+	}
+
+	done := a.startFile(k8sDir)
+
+	k8sPrompt := fmt.Sprintf(`Write the Kubernetes manifests that deploy the %s project.
+Project Description: %s
+Framework: %s
+
+Project files:
+%s
+%s
+Environment variables read by the code: %s
+
+Requirements:
+- Write a ConfigMap, a Deployment and a Service, each named %q with the label app: %s, in the namespace %q
+- Run the image %q in the Deployment, with the container port the code listens on, resource requests and limits, and liveness and readiness probes if the project serves HTTP
+- Put the environment variables in the ConfigMap with example values and load them into the container with envFrom; leave secrets such as passwords, keys and tokens out of the ConfigMap
+- Expose the container port with a ClusterIP Service
+Respond only with valid JSON in the following structure, with each manifest as a YAML document:
+{
+  "configmap": "<ConfigMap YAML>",
+  "deployment": "<Deployment YAML>",
+  "service": "<Service YAML>"
+}`, spec.Name, spec.Description, spec.Framework, strings.Join(filePaths, "\n"), dockerfiles.String(), environment, name, name, namespace, image)
+
+	content, err := a.chat(chatRequest{
+		Label:       k8sDir,
+		Model:       a.CodeModel,
+		System:      "You are an expert in Kubernetes. Respond only with the requested JSON, no explanations or markdown.",
+		Prompt:      a.wrapCodePrompt(k8sPrompt),
+		Temperature: 0.2,
+		JSON:        true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Kubernetes manifests: %v", err)
+	}
+
+	var reply map[string]string
+	if err := a.parseModelJSON(content, &reply); err != nil {
+		return nil, fmt.Errorf("failed to parse Kubernetes manifests: %v", err)
+	}
+
+	var written []string
+	for _, manifest := range k8sManifests {
+		filePath := k8sDir + "/" + manifest.Name
+		document := strings.TrimSpace(reply[strings.ToLower(manifest.Kind)])
+		var object struct {
+			Kind string `yaml:"kind"`
+		}
+		if err := yaml.Unmarshal([]byte(document), &object); err != nil {
+			return written, fmt.Errorf("failed to parse %s: %v", filePath, err)
+		}
+		if object.Kind != manifest.Kind {
+			return written, fmt.Errorf("failed to generate %s: expected a %s, got %q", filePath, manifest.Kind, object.Kind)
+		}
+
+		fileContent := a.finalizeContent(filePath, document+"\n")
+		generatedFiles[filePath] = fileContent
+		if err := writeProjectFile(projectDir, filePath, fileContent); err != nil {
+			return written, err
+		}
+		written = append(written, filePath)
+	}
+	done()
+	return written, nil
+}
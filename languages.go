@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sourceLanguages maps file extensions to the language a file is written
+// in, used to group the files of polyglot projects.
+var sourceLanguages = map[string]string{
+	".go":     "go",
+	".ts":     "typescript",
+	".tsx":    "typescript",
+	".js":     "javascript",
+	".jsx":    "javascript",
+	".mjs":    "javascript",
+	".cjs":    "javascript",
+	".py":     "python",
+	".rs":     "rust",
+	".java":   "java",
+	".kt":     "kotlin",
+	".rb":     "ruby",
+	".php":    "php",
+	".cs":     "csharp",
+	".swift":  "swift",
+	".html":   "web",
+	".css":    "web",
+	".scss":   "web",
+	".vue":    "web",
+	".svelte": "web",
+	".sql":    "sql",
+	".sh":     "shell",
+}
+
+// languagePersonas describes the expert writing each language's files.
+var languagePersonas = map[string]string{
+	"go":         "You are an expert Go programmer who writes idiomatic, gofmt-formatted Go with explicit error handling.",
+	"typescript": "You are an expert TypeScript programmer who writes strictly typed, modern TypeScript.",
+	"javascript": "You are an expert JavaScript programmer who writes modern ES modules.",
+	"python":     "You are an expert Python programmer who writes idiomatic, type-annotated Python that follows PEP 8.",
+	"rust":       "You are an expert Rust programmer who writes safe, idiomatic Rust that passes clippy.",
+	"java":       "You are an expert Java programmer who writes clean, idiomatic modern Java.",
+	"kotlin":     "You are an expert Kotlin programmer who writes idiomatic, null-safe Kotlin.",
+	"ruby":       "You are an expert Ruby programmer who writes idiomatic Ruby.",
+	"php":        "You are an expert PHP programmer who writes modern, strictly typed PHP.",
+	"csharp":     "You are an expert C# programmer who writes idiomatic modern C#.",
+	"swift":      "You are an expert Swift programmer who writes idiomatic, safe Swift.",
+	"web":        "You are an expert frontend developer who writes accessible, semantic markup and maintainable styles.",
+	"sql":        "You are an expert database engineer who writes portable, well-indexed SQL.",
+	"shell":      "You are an expert shell programmer who writes portable, defensive shell scripts.",
+}
+
+// sourceLanguage returns the language of a file, or an empty string if it
+// is not recognized.
+func sourceLanguage(filePath string) string {
+	return sourceLanguages[strings.ToLower(filepath.Ext(filePath))]
+}
+
+// isSourceLanguage reports whether language is a known language name.
+func isSourceLanguage(language string) bool {
+	_, ok := languagePersonas[language]
+	return ok
+}
+
+// languageNames returns the known language names in alphabetical order.
+func languageNames() []string {
+	var names []string
+	for language := range languagePersonas {
+		names = append(names, language)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// codeModelFor returns the model that generates filePath, which is the
+// model configured for its language or else CodeModel.
+func (a *DevAgent) codeModelFor(filePath string) string {
+	if model, ok := a.LanguageModels[sourceLanguage(filePath)]; ok {
+		return model
+	}
+	return a.CodeModel
+}
+
+// codeSystemPromptFor returns the system prompt for generating filePath. A
+// custom CodeSystemPrompt applies to every file; otherwise the persona of
+// the file's language is used.
+func (a *DevAgent) codeSystemPromptFor(filePath string) string {
+	persona, ok := languagePersonas[sourceLanguage(filePath)]
+	if a.CodeSystemPrompt != defaultCodeSystemPrompt || !ok {
+		return a.CodeSystemPrompt + a.styleGuideInstruction() + a.styleInstruction(filePath)
+	}
+	return persona + " Generate only the code, no explanations or markdown." + a.styleGuideInstruction() + a.styleInstruction(filePath)
+}
+
+// groupByLanguage orders files so that each language is generated as a
+// group, keeping the given order within a group. Files of unknown language
+// come last. It returns the languages in group order.
+func groupByLanguage(filePaths []string) []string {
+	var languages []string
+	seen := make(map[string]bool)
+	for _, filePath := range filePaths {
+		if language := sourceLanguage(filePath); language != "" && !seen[language] {
+			seen[language] = true
+			languages = append(languages, language)
+		}
+	}
+	sort.Strings(languages)
+
+	rank := make(map[string]int, len(languages))
+	for i, language := range languages {
+		rank[language] = i
+	}
+	rankOf := func(filePath string) int {
+		if r, ok := rank[sourceLanguage(filePath)]; ok {
+			return r
+		}
+		return len(languages)
+	}
+	sort.SliceStable(filePaths, func(i, j int) bool {
+		return rankOf(filePaths[i]) < rankOf(filePaths[j])
+	})
+	return languages
+}
+
+// describeLanguageGroups summarizes how many files each language has.
+func describeLanguageGroups(filePaths, languages []string) string {
+	counts := make(map[string]int)
+	for _, filePath := range filePaths {
+		counts[sourceLanguage(filePath)]++
+	}
+	var parts []string
+	for _, language := range languages {
+		parts = append(parts, fmt.Sprintf("%s (%d)", language, counts[language]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// listFormats are the output formats of -list-files.
+var listFormats = []string{"table", "json"}
+
+// listedFile is one entry of the -list-files JSON output.
+type listedFile struct {
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+// printFileList writes the files of spec with their descriptions to w, in
+// generation order, as an aligned table or as a JSON array.
+func printFileList(w io.Writer, spec *ProjectSpec, format string) error {
+	spec = withoutDependsHints(spec)
+	filePaths := generationOrder(spec)
+
+	switch format {
+	case "json":
+		files := make([]listedFile, 0, len(filePaths))
+		for _, filePath := range filePaths {
+			files = append(files, listedFile{Path: filePath, Description: spec.Files[filePath]})
+		}
+		data, err := json.MarshalIndent(files, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal file list: %v", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "PATH\tDESCRIPTION")
+		for _, filePath := range filePaths {
+			// Keep each file on one row
+			description := strings.Join(strings.Fields(spec.Files[filePath]), " ")
+			fmt.Fprintf(tw, "%s\t%s\n", filePath, description)
+		}
+		fmt.Fprintf(tw, "\n%d files\n", len(filePaths))
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown list format %q (use one of %s)", format, strings.Join(listFormats, ", "))
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger prints leveled status messages. Debug messages are only shown in
+// verbose mode. With a Sink, messages are sent there as structured records
+// instead of being printed.
+type Logger struct {
+	Out     io.Writer
+	Verbose bool
+	Sink    logSink
+	// ErrorLog, if set, also receives every error with a timestamp, to
+	// review the failures of a long run afterwards
+	ErrorLog io.Writer
+
+	mu sync.Mutex
+}
+
+// stdout receives all console output except generated content written in
+// stdout mode. It is a plainWriter when emoji are turned off and a
+// colorWriter when output is colored.
+var stdout io.Writer = os.Stdout
+
+// stderr receives log output and errors in modes that write generated
+// content to stdout. It is wrapped like stdout.
+var stderr io.Writer = os.Stderr
+
+func NewLogger() *Logger {
+	return &Logger{Out: stdout}
+}
+
+// printf prints a message of the given level, or sends it to the sink.
+// A record the sink fails to take is printed instead.
+func (l *Logger) printf(level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	message := fmt.Sprintf(format, args...)
+	if level == levelError {
+		l.logError(message)
+	}
+	if l.Sink != nil && l.Sink.Log(level, statusMessage(message)) == nil {
+		return
+	}
+	fmt.Fprintln(l.Out, message)
+}
+
+// RecordError writes an error only to the ErrorLog, for errors that are
+// reported on the console by other means.
+func (l *Logger) RecordError(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logError(fmt.Sprintf(format, args...))
+}
+
+// logError appends message to the ErrorLog as one plain line.
+func (l *Logger) logError(message string) {
+	if l.ErrorLog == nil {
+		return
+	}
+	fmt.Fprintf(l.ErrorLog, "%s %s\n", time.Now().Format(time.RFC3339), plainText(statusMessage(message)))
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	if l.Verbose {
+		l.printf(levelDebug, "🔍 "+format, args...)
+	}
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.printf(levelInfo, format, args...)
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.printf(levelWarn, "⚠️  "+format, args...)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.printf(levelError, "❌ "+format, args...)
+}
+
+// emojiTags are the ASCII tags that replace the emoji prefixing status
+// messages; other emoji become [INFO].
+var emojiTags = map[rune]string{
+	'⚠': "[WARN]",
+	'❌': "[ERROR]",
+	'🔍': "[DEBUG]",
+	'✅': "[OK]",
+	'✨': "[DONE]",
+	'⏭': "[SKIP]",
+}
+
+// plainSymbols are ASCII replacements for other non-ASCII symbols in status
+// output.
+var plainSymbols = map[rune]string{
+	'│': "|",
+	'├': "|",
+	'└': "`",
+	'─': "-",
+	'—': "-",
+	'…': "...",
+	'⤷': "->",
+	'↪': "->",
+}
+
+// isEmoji reports whether r is an emoji such as those prefixing status
+// messages. Arrows, math operators, box drawing and CJK characters, which
+// spec JSON and file previews may hold, are left alone.
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x23E9 && r <= 0x23FA: // media controls, e.g. ⏭
+	case r >= 0x2600 && r <= 0x27BF: // miscellaneous symbols and dingbats, e.g. ⚠ ✅
+	case r >= 0x1F300 && r <= 0x1F6FF: // pictographs, emoticons and transport, e.g. 📁 🚀
+	case r >= 0x1F900 && r <= 0x1FAFF: // supplemental pictographs, e.g. 🧪 🩹
+	default:
+		return false
+	}
+	return true
+}
+
+// plainText replaces emoji in s with ASCII tags and other symbols with
+// ASCII equivalents. The spaces that aligned an emoji collapse to one.
+func plainText(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r < 0x80:
+			b.WriteRune(r)
+		case r == 0xFE0F || r == 0x200D:
+			// Variation selectors and joiners only shape the emoji before them
+		case plainSymbols[r] != "":
+			b.WriteString(plainSymbols[r])
+		case isEmoji(r):
+			tag, ok := emojiTags[r]
+			if !ok {
+				tag = "[INFO]"
+			}
+			b.WriteString(tag)
+			j := i + 1
+			for j < len(runes) && (runes[j] == 0xFE0F || runes[j] == 0x200D) {
+				j++
+			}
+			k := j
+			for k < len(runes) && runes[k] == ' ' {
+				k++
+			}
+			if k > j {
+				b.WriteByte(' ')
+			}
+			i = k - 1
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// plainWriter writes to w with emoji replaced by ASCII tags.
+type plainWriter struct {
+	w io.Writer
+}
+
+func (p plainWriter) Write(data []byte) (int, error) {
+	if _, err := io.WriteString(p.w, plainText(string(data))); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
@@ -4,12 +4,16 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/sashabaranov/go-openai"
 )
@@ -21,203 +25,859 @@ type ProjectSpec struct {
 	Components  []string          `json:"components"`
 	Files       map[string]string `json:"files"`
 	Description string            `json:"description"`
+	UIFiles     []string          `json:"ui_files,omitempty"`
+	LargeFiles  []string          `json:"large_files,omitempty"`
+	Snippets    map[string]string `json:"snippets,omitempty"`
+	Order       []string          `json:"order,omitempty"`
+	// ExcludeFromContext lists files, such as large generated data, whose
+	// content is left out of the context of later files
+	ExcludeFromContext []string `json:"exclude_from_context,omitempty"`
+
+	// dependencies holds the depends hints stripped from the descriptions
+	// of Files, keyed by file
+	dependencies map[string][]string
 }
 
+// defaultMaxConcurrencyPerDir keeps files in the same directory, such as
+// index files that import each other, from all being generated at once.
+const defaultMaxConcurrencyPerDir = 2
+
+// defaultCodeSystemPrompt is the system prompt for code generation unless
+// it is replaced with -code-system-file.
+const defaultCodeSystemPrompt = "You are an expert programmer. Generate only the code, no explanations or markdown."
+
 type DevAgent struct {
-	client *openai.Client
-	ctx    context.Context
+	ctx context.Context
+
+	// Providers are tried in order until one produces a response
+	Providers  []Provider
+	producedBy sync.Map
+
+	// summaries caches file summaries by path and content hash
+	summariesMu sync.Mutex
+	summaries   map[string]string
+
+	// oversized lists the files truncated to MaxFileSize in this run
+	oversizedMu sync.Mutex
+	oversized   []string
+
+	// Models used for each generation phase
+	SpecModel   string
+	CodeModel   string
+	ReadmeModel string
+	// EnhanceModel expands terse prompts before planning, see EnhancePrompt
+	EnhanceModel string
+	// SummaryModel summarizes files that do not fit the README prompt
+	SummaryModel string
+	// LanguageModels overrides CodeModel for the files of a language,
+	// e.g. "go" or "typescript"
+	LanguageModels map[string]string
+
+	// SpecTemplate renders the system prompt for planning a spec and
+	// CodeTemplate the prompt for generating a file
+	SpecTemplate *template.Template
+	CodeTemplate *template.Template
+
+	// Profile names the entry of Profiles whose instructions are added to
+	// the spec and code prompts; empty means none
+	Profile  string
+	Profiles map[string]Profile
+
+	// StyleExamples maps file names to exemplar code whose style generated
+	// code should follow; they are added to the code system prompt
+	StyleExamples map[string]string
+	// StyleGuide is a coding style document, such as an organization's
+	// conventions, added to the system prompt of every code generation
+	StyleGuide string
+
+	// CodeSystemPrompt is the system prompt used when generating code.
+	// While it is the default, each language uses its own persona instead.
+	CodeSystemPrompt string
+
+	// PromptPrefix and PromptSuffix are added before and after every code
+	// generation prompt
+	PromptPrefix string
+	PromptSuffix string
+
+	// FrameworkExtensions maps framework keywords to the file extensions
+	// expected in their projects
+	FrameworkExtensions map[string][]string
+	// FixExtensions re-prompts for a spec whose files do not match its
+	// framework
+	FixExtensions bool
+
+	// KeepGoing continues with the remaining files when one fails to
+	// generate or cannot be written; the run then returns a PartialError
+	KeepGoing bool
+	// WithTests generates a test file alongside each source file
+	WithTests bool
+
+	// OutputDir is the directory projects are generated in, e.g. a
+	// package path inside a monorepo
+	OutputDir string
+	// Name replaces the project name the model suggests, naming the project
+	// directory and README
+	Name string
+	// OnExists decides what happens when the project directory already
+	// exists: merge, overwrite, skip or error
+	OnExists string
+	// ImportBase is the module path of the repository OutputDir lives in
+	ImportBase string
+	// Isolate generates each project into a new temporary directory
+	// instead of below OutputDir, leaving the final placement to the
+	// caller; RemoveIsolated deletes it again once generation and the post
+	// hook are done
+	Isolate        bool
+	RemoveIsolated bool
+
+	// GitBranch, if set, is checked out, and created if needed, in the git
+	// repository holding the project before generating; GitCommit commits the
+	// generated files to it
+	GitBranch string
+	GitCommit bool
+	// Changelog adds an entry for the generated files to the CHANGELOG.md
+	// of the repository GitBranch is created in
+	Changelog bool
+
+	// BaseContext maps paths to the content of existing files, such as the
+	// surrounding codebase, that every generated file sees as context.
+	// They are never written or regenerated.
+	BaseContext map[string]string
+
+	// ContextTopK limits the context of each file to the previous files
+	// whose descriptions are most similar, using EmbeddingModel
+	ContextTopK    int
+	EmbeddingModel openai.EmbeddingModel
+	// ContextWindow limits the context of each file to the N files
+	// generated last; 0 includes all of them
+	ContextWindow int
+	// BatchFiles generates up to N small files with one request, split
+	// from the reply by delimiters; 0 or 1 gives each file its own. A
+	// custom CodeTemplate, or a Theme for UI files, keeps files out of
+	// batches
+	BatchFiles int
+
+	// ChunkLargeFiles generates files the spec marks as large section by
+	// section from an outline
+	ChunkLargeFiles bool
+
+	// MaxRuntime stops generating a project once this much time has passed,
+	// after the files in progress are finished (0 means no limit)
+	MaxRuntime time.Duration
+
+	// ResumeFrom regenerates the project from this file onward, using the
+	// files before it on disk as context
+	ResumeFrom string
+
+	// Concurrency is how many files are generated at the same time, and
+	// MaxConcurrencyPerDir limits that within a single directory
+	Concurrency          int
+	MaxConcurrencyPerDir int
+
+	// Incremental only regenerates files whose spec description changed
+	// since the last run
+	Incremental bool
+
+	// StrictJSON rejects JSON replies that are fenced, truncated or
+	// otherwise need repair, instead of fixing them up
+	StrictJSON bool
+	// Strict fails on model misbehavior, such as an empty file, instead of
+	// retrying
+	Strict bool
+
+	// Header, such as a license notice, is added to the top of every
+	// generated file as a comment in the file's syntax
+	Header string
+
+	// RedactSecrets replaces credentials in generated content with
+	// placeholders
+	RedactSecrets bool
+
+	// GoBuild runs go build on generated Go projects
+	GoBuild bool
+
+	// SkipExisting only generates files that do not exist yet; existing
+	// files are left untouched and used as context
+	SkipExisting bool
+
+	// SummaryFile is where a markdown summary of the generated project is
+	// written, if set
+	SummaryFile string
+
+	// PostHook is a shell command run in the project directory after
+	// generation; PostHookFailOnError fails the run if it exits non-zero
+	PostHook            string
+	PostHookFailOnError bool
+
+	// NoReadme leaves out the README.md, as -extras does without readme
+	NoReadme bool
+
+	// Makefile adds a Makefile with build, test, run and lint targets
+	Makefile bool
+
+	// CI adds a CI workflow for this provider, e.g. github or gitlab
+	CI string
+
+	// Migrations adds database migrations for this tool, e.g. goose, when
+	// the project uses a database
+	Migrations string
+
+	// EnvExample adds a .env.example listing the environment variables the
+	// generated code reads
+	EnvExample bool
+
+	// ProjectCacheDir caches whole generated projects keyed by the hash of
+	// their spec and generation options, so an identical spec generated
+	// with the same options is restored without API calls
+	ProjectCacheDir string
+
+	// GoWorkspace generates a go.mod per module and a go.work tying them
+	// together when the spec plans several Go modules
+	GoWorkspace bool
+
+	// K8s adds a Deployment, Service and ConfigMap under k8s/ that run
+	// K8sImage, or an image named after the project, in K8sNamespace
+	K8s          bool
+	K8sNamespace string
+	K8sImage     string
+
+	// Diagram adds docs/architecture.md with a Mermaid diagram of the
+	// project's components
+	Diagram bool
+
+	// CommunityFiles adds community health files such as CONTRIBUTING.md
+	// and CODE_OF_CONDUCT.md, rendered from templates
+	CommunityFiles bool
+
+	// Format runs the language's formatter on generated files
+	Format bool
+
+	// MaxFileSize truncates generated files larger than this many bytes
+	// (0 means no limit)
+	MaxFileSize int
+
+	// PreviewLines is how many lines of each generated file are printed
+	PreviewLines int
+
+	// Theme is the design theme applied to UI files, e.g. dark or minimal
+	Theme string
+
+	// Snippets maps names to boilerplate that files reference instead of
+	// regenerating; they extend the snippets declared in the spec
+	Snippets map[string]string
+
+	// ReasoningEffort, Verbosity and ServiceTier are sent with chat
+	// requests to the models that support them, e.g. reasoning effort to
+	// o-series models
+	ReasoningEffort string
+	Verbosity       string
+	ServiceTier     string
+
+	// MaxRetries is how often a transient API failure is retried
+	MaxRetries int
+	// RetryStatuses are the HTTP statuses treated as transient; nil means
+	// 429 and 5xx
+	RetryStatuses map[int]bool
+	// ContextUpgrades maps models to a larger-context model that requests
+	// are retried with when their prompt exceeds the context window
+	ContextUpgrades map[string]string
+	// ModelStyles maps model name prefixes to prompt adjustments applied
+	// to every request to a matching model
+	ModelStyles map[string]ModelStyle
+	// FixAttempts is how often output that fails validation, such as a
+	// spec with mismatched files or code that does not compile, is sent
+	// back to the model. Each attempt retries API failures on its own.
+	FixAttempts int
+	// FixTemperatureStep raises the temperature of each fix attempt by
+	// this much, so it is less likely to repeat the rejected output
+	FixTemperatureStep float32
+	// Usage accumulates the tokens consumed by every API call
+	Usage *Usage
+
+	Log *Logger
+	// Observer is notified about the progress of each file
+	Observer Observer
+	// ReviewFile, when set, is shown each generated file before it is
+	// written and answers whether to write, regenerate or skip it
+	ReviewFile func(filePath, content string) string
 }
 
 func NewDevAgent(apiKey string) *DevAgent {
-	return &DevAgent{
-		client: openai.NewClient(apiKey),
-		ctx:    context.Background(),
+	return NewDevAgentWithHTTPClient(apiKey, nil)
+}
+
+// NewDevAgentWithHTTPClient is like NewDevAgent but sends API requests
+// through httpClient, e.g. one configured for a proxy. A nil httpClient
+// uses the library default.
+func NewDevAgentWithHTTPClient(apiKey string, httpClient *http.Client) *DevAgent {
+	log := NewLogger()
+	agent := &DevAgent{
+		ctx:         context.Background(),
+		Providers:   []Provider{&compatibleProvider{name: "openai", client: newClient(openai.DefaultConfig(apiKey), httpClient)}},
+		SpecModel:   openai.GPT4o,
+		CodeModel:   openai.GPT4Turbo,
+		ReadmeModel: openai.GPT4o,
+
+		EnhanceModel: defaultEnhanceModel,
+		SummaryModel: defaultSummaryModel,
+
+		EmbeddingModel: defaultEmbeddingModel,
+
+		SpecTemplate:     defaultSpecTmpl,
+		CodeTemplate:     defaultCodeTmpl,
+		CodeSystemPrompt: defaultCodeSystemPrompt,
+
+		LanguageModels:      make(map[string]string),
+		ContextUpgrades:     make(map[string]string),
+		ModelStyles:         make(map[string]ModelStyle),
+		Profiles:            make(map[string]Profile),
+		FrameworkExtensions: make(map[string][]string),
+		OnExists:            onExistsMerge,
+
+		Concurrency:          1,
+		MaxConcurrencyPerDir: defaultMaxConcurrencyPerDir,
+
+		MaxRetries:         defaultMaxRetries,
+		FixAttempts:        defaultFixAttempts,
+		FixTemperatureStep: defaultFixTemperatureStep,
+
+		Usage:    &Usage{},
+		Log:      log,
+		Observer: &logObserver{log: log},
+	}
+	for keyword, extensions := range defaultFrameworkExtensions {
+		agent.FrameworkExtensions[keyword] = extensions
+	}
+	for name, profile := range defaultProfiles {
+		agent.Profiles[name] = profile
+	}
+	for model, upgrade := range defaultContextUpgrades {
+		agent.ContextUpgrades[model] = upgrade
 	}
+	for prefix, style := range defaultModelStyles {
+		agent.ModelStyles[prefix] = style
+	}
+	return agent
 }
 
+// GenerateProjectSpec asks the model to plan a project for the prompt. The
+// spec's files are checked against its framework, and with FixExtensions
+// the model is asked to correct mismatched files, up to FixAttempts times.
 func (a *DevAgent) GenerateProjectSpec(prompt string) (*ProjectSpec, error) {
-	systemPrompt := `As an AI development agent, analyze the user's request and create a detailed project specification.
-Think through this step by step:
-
-1. Understand the core requirements
-2. Identify the best framework and technologies
-3. Break down the components needed
-4. Plan the file structure
-5. Create a comprehensive project specification
-
-Generate a JSON project specification that includes:
-- Project name
-- Project type (web, mobile, cli, etc.)
-- Framework recommendation
-- List of required components
-- File structure (provide all the files and their descriptions required for production ready code)
-- Project description
-
-Respond only with valid JSON in the following structure:
-{
-  "name": "<project name>",
-  "type": "<project type>",
-  "framework": "<recommended framework>",
-  "components": [
-    "<component 1>",
-    "<component 2>",
-    ...
-  ],
-  "files": {
-    "<file 1 path>": "<file 1 description and prompt to generate file and import chains>",
-    "<file 2 path>": "<file 2 description and prompt to generate file and import chains>",
-    ...
-  },
-  "description": "<project description>"
-}`
-
-	resp, err := a.client.CreateChatCompletion(
-		a.ctx,
-		openai.ChatCompletionRequest{
-			Model: openai.GPT4o,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: systemPrompt,
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: prompt,
-				},
-			},
-			Temperature: 0.2,
-		},
-	)
+	spec, err := a.generateValidSpec(prompt, baseTemperature)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches := checkFileExtensions(spec, a.FrameworkExtensions)
+	for _, mismatch := range mismatches {
+		a.Log.Warnf("%s", mismatch)
+	}
+	for attempt := 1; len(mismatches) > 0 && a.FixExtensions && attempt <= a.FixAttempts; attempt++ {
+		temperature := a.fixTemperature(attempt)
+		a.Log.Infof("🔁 Re-planning to fix mismatched files (fix attempt %d/%d, temperature %.2f)...", attempt, a.FixAttempts, temperature)
+		spec, err = a.generateValidSpec(fmt.Sprintf("%s\n\nA previous plan had these problems, avoid them:\n- %s", prompt, strings.Join(mismatches, "\n- ")), temperature)
+		if err != nil {
+			return nil, err
+		}
+		mismatches = checkFileExtensions(spec, a.FrameworkExtensions)
+		for _, mismatch := range mismatches {
+			a.Log.Warnf("%s", mismatch)
+		}
+	}
+	return spec, nil
+}
+
+// generateValidSpec plans a spec and, while it does not match the spec
+// schema, asks the model again with the failed constraints, up to
+// FixAttempts times.
+func (a *DevAgent) generateValidSpec(prompt string, temperature float32) (*ProjectSpec, error) {
+	spec, err := a.generateProjectSpec(prompt, temperature)
+	var invalid *schemaError
+	for attempt := 1; errors.As(err, &invalid) && attempt <= a.FixAttempts; attempt++ {
+		a.Log.Warnf("%v", err)
+		fixTemperature := a.fixTemperature(attempt)
+		a.Log.Infof("🔁 Re-planning to match the spec schema (fix attempt %d/%d, temperature %.2f)...", attempt, a.FixAttempts, fixTemperature)
+		spec, err = a.generateProjectSpec(fmt.Sprintf("%s\n\nA previous plan did not match the required JSON structure, fix these problems:\n- %s", prompt, strings.Join(invalid.Problems, "\n- ")), fixTemperature)
+	}
+	return spec, err
+}
+
+func (a *DevAgent) generateProjectSpec(prompt string, temperature float32) (*ProjectSpec, error) {
+	systemPrompt, err := renderPrompt(a.SpecTemplate, specPromptData{Prompt: prompt, ProfileInstruction: a.specProfileInstruction()})
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := a.chatReply(chatRequest{
+		Label:       "project spec",
+		Model:       a.SpecModel,
+		System:      systemPrompt,
+		Prompt:      prompt,
+		Temperature: temperature,
+		JSON:        true,
+	})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate project spec: %v", err)
 	}
 
-	var spec ProjectSpec
-	respContent := strings.TrimSpace(resp.Choices[0].Message.Content)
-	// Remove markdown code block if present
-	respContent = strings.TrimPrefix(respContent, "```json")
-	respContent = strings.TrimSuffix(respContent, "```")
-	respContent = strings.TrimSpace(respContent)
+	content := reply.Content
+	continuations := 0
+	if reply.truncated() && a.StrictJSON {
+		return nil, fmt.Errorf("failed to generate project spec: the reply was truncated at the model's output limit")
+	}
+	if reply.truncated() {
+		content, continuations, err = a.continueSpec(stripJSONFences(content))
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	err = json.Unmarshal([]byte(respContent), &spec)
+	// Decoding ignores unknown fields and stops at the first wrong type;
+	// the schema reports every mismatch
+	if !a.StrictJSON {
+		content = stripJSONFences(content)
+	}
+	var spec ProjectSpec
+	err = checkSpecSchema(content)
+	if err == nil {
+		err = json.Unmarshal([]byte(content), &spec)
+	}
+	var invalid *schemaError
+	if errors.As(err, &invalid) {
+		return nil, err
+	}
 	if err != nil {
+		if continuations > 0 {
+			return nil, fmt.Errorf("failed to parse project spec after recovering it from truncation: %v", err)
+		}
 		return nil, fmt.Errorf("failed to parse project spec: %v", err)
 	}
+	if continuations > 0 {
+		a.Log.Infof("🩹 Recovered the truncated project spec with %d continuation(s)", continuations)
+	}
+
+	err = validateSpec(&spec)
+	if err != nil {
+		return nil, err
+	}
 
 	return &spec, nil
 }
 
+// GenerateFile generates the content of a single file from the spec and
+// returns it without writing to disk. The context maps the paths of
+// previously generated files to their content. Depends hints in the spec
+// are left out of the prompt.
+func (a *DevAgent) GenerateFile(spec *ProjectSpec, filePath string, context map[string]string) (string, error) {
+	spec = withoutDependsHints(spec)
+	description, ok := spec.Files[filePath]
+	if !ok {
+		return "", fmt.Errorf("file %s is not part of the project spec", filePath)
+	}
+
+	if a.ChunkLargeFiles && isLargeFile(spec, filePath) {
+		return a.generateLargeFile(spec, filePath, context)
+	}
+
+	render := func(context map[string]string) (string, error) {
+		codePrompt, err := renderPrompt(a.CodeTemplate, a.promptDataFor(spec, filePath, description, context))
+		if err != nil {
+			return "", err
+		}
+		return a.wrapCodePrompt(codePrompt), nil
+	}
+	prompt, err := render(context)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := a.chat(chatRequest{
+		Label:       filePath,
+		Model:       a.codeModelFor(filePath),
+		System:      a.codeSystemPromptFor(filePath),
+		Prompt:      prompt,
+		Temperature: 0.2,
+		Prune:       pruneContext(context, render),
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code for %s: %v", filePath, err)
+	}
+
+	return a.spliceSnippets(spec, filePath, stripCodeFences(filePath, content)), nil
+}
+
+// promptDataFor returns what the code template renders for filePath with
+// the given description and context.
+func (a *DevAgent) promptDataFor(spec *ProjectSpec, filePath, description string, context map[string]string) codePromptData {
+	return codePromptData{
+		Spec:               spec,
+		FilePath:           filePath,
+		Description:        description,
+		ProfileInstruction: a.codeProfileInstruction(),
+		ImportInstruction:  a.importInstruction(spec),
+		ThemeInstruction:   a.themeInstruction(spec, filePath),
+		SnippetInstruction: a.snippetInstruction(spec),
+		Context:            describeContext(context),
+		Files:              context,
+	}
+}
+
+// describeContext lists previously generated files for a prompt.
+func describeContext(context map[string]string) string {
+	if len(context) == 0 {
+		return ""
+	}
+	var contextBuilder strings.Builder
+	contextBuilder.WriteString("\nPreviously generated files:\n")
+	for prevPath, content := range context {
+		contextBuilder.WriteString(fmt.Sprintf("\n%s:\n```\n%s\n```\n", prevPath, content))
+	}
+	return contextBuilder.String()
+}
+
+// GenerateCode generates every file in the spec and writes the project to
+// disk, followed by its dependency manifests and README. With Isolate the
+// project goes to a temporary directory, see GenerateCodeIsolated.
 func (a *DevAgent) GenerateCode(spec *ProjectSpec) error {
-	fmt.Printf("🚀 Generating project: %s\n", spec.Name)
-	fmt.Printf("📋 Type: %s using %s\n", spec.Type, spec.Framework)
-	fmt.Println("📁 Generating files...")
+	spec = a.named(spec)
+	if a.Isolate {
+		_, err := a.GenerateCodeIsolated(spec)
+		return err
+	}
+	if a.GitBranch != "" {
+		return a.generateOnBranch(spec)
+	}
+	return a.generateCode(spec, a.ProjectDir(spec))
+}
+
+// generateCode generates the project into projectDir.
+func (a *DevAgent) generateCode(spec *ProjectSpec, projectDir string) error {
+	start := time.Now()
+	spec = withoutDependsHints(spec)
+	a.Log.Infof("🚀 Generating project: %s", spec.Name)
+	a.takeOversized()
+	a.Log.Infof("📋 Type: %s using %s", spec.Type, spec.Framework)
+	a.Log.Infof("📁 Generating files...")
+	if len(a.BaseContext) > 0 {
+		a.Log.Infof("📚 Using %d existing file(s) as context", len(a.BaseContext))
+	}
+	if a.PromptPrefix != "" {
+		a.Log.Debugf("Code prompt prefix: %q", a.PromptPrefix)
+	}
+	if a.PromptSuffix != "" {
+		a.Log.Debugf("Code prompt suffix: %q", a.PromptSuffix)
+	}
+
+	if err := a.validateWorkspace(spec); err != nil {
+		return err
+	}
 
 	// Create project directory
-	projectDir := spec.Name
-	err := os.MkdirAll(projectDir, 0755)
-	if err != nil {
-		return fmt.Errorf("failed to create project directory: %v", err)
+	proceed, err := a.prepareProjectDir(spec, projectDir)
+	if err != nil || !proceed {
+		return err
+	}
+
+	// An identical spec generated before is restored without API calls
+	if a.ProjectCacheDir != "" && a.ResumeFrom == "" {
+		cached, err := a.restoreCachedProject(spec, projectDir)
+		if err != nil {
+			return err
+		}
+		if cached != nil {
+			a.Log.Infof("✨ Project restored from cache!")
+			a.writeSummary(spec, projectDir, cached)
+			return a.runPostHook(spec, projectDir)
+		}
 	}
 
-	// Keep track of generated files and their content
-	generatedFiles := make(map[string]string)
+	filePaths := generationOrder(spec)
+
+	// When resuming, files before the resume point are reused from disk
+	resumeIndex := 0
+	if a.ResumeFrom != "" {
+		resumeIndex = indexOf(filePaths, a.ResumeFrom)
+		if resumeIndex < 0 {
+			return fmt.Errorf("cannot resume from %s: the file is not part of the project spec", a.ResumeFrom)
+		}
+	}
 
-	// Sort files to ensure consistent generation order
-	var filePaths []string
-	for filePath := range spec.Files {
-		filePaths = append(filePaths, filePath)
+	// In incremental mode, files whose description is unchanged since the
+	// last run are reused from disk instead of being regenerated
+	var previous *Manifest
+	if a.Incremental || a.ResumeFrom != "" {
+		previous, err = ReadManifest(projectDir)
+		if err != nil {
+			return err
+		}
 	}
-	sort.Strings(filePaths)
 
-	for _, filePath := range filePaths {
-		description := spec.Files[filePath]
-		fmt.Printf("⚙️  Generating %s...\n", filePath)
+	run := &generationRun{
+		agent:       a,
+		spec:        spec,
+		projectDir:  projectDir,
+		baseContext: a.BaseContext,
+		files:       make(map[string]string),
+		manifest:    NewManifest(spec),
+	}
+	generatedFiles, manifest := run.files, run.manifest
+	var toGenerate, skipped []string
+	kept := make(map[string]bool)
 
-		// Build context from previously generated files
-		var contextBuilder strings.Builder
-		if len(generatedFiles) > 0 {
-			contextBuilder.WriteString("\nPreviously generated files:\n")
-			for prevPath, content := range generatedFiles {
-				contextBuilder.WriteString(fmt.Sprintf("\n%s:\n```\n%s\n```\n", prevPath, content))
+	// reuse loads an existing file and its tests from disk as context and
+	// keeps their manifest entries
+	reuse := func(filePath string) bool {
+		content, err := os.ReadFile(filepath.Join(projectDir, filePath))
+		if err != nil {
+			return false
+		}
+		generatedFiles[filePath] = string(content)
+		if previous == nil || previous.Entry(filePath) == nil {
+			manifest.Add(filePath, fileKindSource)
+			return true
+		}
+		manifest.Files = append(manifest.Files, *previous.Entry(filePath))
+		for _, test := range previous.TestsFor(filePath) {
+			if content, err := os.ReadFile(filepath.Join(projectDir, test.Path)); err == nil {
+				generatedFiles[test.Path] = string(content)
+				manifest.Files = append(manifest.Files, test)
 			}
 		}
+		return true
+	}
 
-		codePrompt := fmt.Sprintf(`Generate the complete code for the file %s in the %s project.
-Project Description: %s
-File Purpose: %s
+	for i, filePath := range filePaths {
+		if i < resumeIndex {
+			if reuse(filePath) {
+				a.Log.Infof("📄 Using existing %s as context", filePath)
+			} else {
+				a.Log.Warnf("%s does not exist yet and is not available as context", filePath)
+			}
+			continue
+		}
 
-Requirements:
-- Use %s framework
-- Follow best practices
-- Include necessary imports
-- Add helpful comments
-- Make sure the code is complete and functional
-- Ensure compatibility with other project files
-%s
-Generate only the code, no explanations.`, filePath, spec.Name, spec.Description, description, spec.Framework, contextBuilder.String())
-
-		resp, err := a.client.CreateChatCompletion(
-			a.ctx,
-			openai.ChatCompletionRequest{
-				Model: openai.GPT4Turbo,
-				Messages: []openai.ChatCompletionMessage{
-					{
-						Role:    openai.ChatMessageRoleSystem,
-						Content: "You are an expert programmer. Generate only the code, no explanations or markdown.",
-					},
-					{
-						Role:    openai.ChatMessageRoleUser,
-						Content: codePrompt,
-					},
-				},
-				Temperature: 0.2,
-			},
-		)
-
-		if err != nil {
-			return fmt.Errorf("failed to generate code for %s: %v", filePath, err)
-		}
-
-		fileContent := resp.Choices[0].Message.Content
-		// Remove markdown code blocks if present
-		fileContent = strings.TrimPrefix(fileContent, "```")
-		fileContent = strings.TrimSuffix(fileContent, "```")
-		fileContent = strings.TrimSpace(fileContent)
-
-		// Remove language identifier if present (e.g., ```javascript)
-		if idx := strings.Index(fileContent, "\n"); idx != -1 {
-			if !strings.Contains(fileContent[:idx], "=") && !strings.Contains(fileContent[:idx], ":") {
-				fileContent = fileContent[idx+1:]
+		if a.keepExisting(projectDir, filePath) && reuse(filePath) {
+			kept[filePath] = true
+			continue
+		}
+
+		hash := descriptionHash(spec.Files[filePath])
+		if a.Incremental && previous != nil {
+			if entry := previous.Entry(filePath); entry != nil && entry.DescriptionHash == hash && reuse(filePath) {
+				a.Log.Infof("⏭️  Skipping %s (unchanged)", filePath)
+				skipped = append(skipped, filePath)
+				continue
+			}
+		}
+
+		toGenerate = append(toGenerate, filePath)
+	}
+
+	// Generate each language of a polyglot project as a group, unless the
+	// spec sets the order
+	if len(spec.Order) > 0 || len(spec.dependencies) > 0 {
+		a.Log.Debugf("Generating in the order given by the spec")
+	} else if languages := groupByLanguage(toGenerate); len(languages) > 1 {
+		a.Log.Infof("🌐 Generating by language: %s", describeLanguageGroups(toGenerate, languages))
+	}
+
+	// Select context by similarity of file descriptions, falling back to
+	// all previous files if embeddings are unavailable
+	if a.ContextTopK > 0 && len(toGenerate) > 0 {
+		run.embeddings, err = a.embedDescriptions(spec, projectDir)
+		if err != nil {
+			a.Log.Warnf("Using all previous files as context: %v", err)
+		}
+	}
+
+	if a.MaxRuntime > 0 {
+		run.deadline = start.Add(a.MaxRuntime)
+	}
+	err = run.generateFiles(toGenerate)
+	if errors.Is(err, errMaxRuntime) {
+		return a.stopAtMaxRuntime(projectDir, run, toGenerate)
+	}
+	if err != nil {
+		if !isWriteError(err) {
+			return err
+		}
+		if notePath := writeRecoveryNote(projectDir, run.written, run.notWritten(toGenerate), err); notePath != "" {
+			return fmt.Errorf("%v (see %s)", err, notePath)
+		}
+		return err
+	}
+
+	if len(skipped) > 0 {
+		a.Log.Infof("⏭️  Skipped %d unchanged file(s): %s", len(skipped), strings.Join(skipped, ", "))
+	}
+	if len(run.emptyRetried) > 0 {
+		a.Log.Warnf("Retried %d file(s) that were generated empty: %s", len(run.emptyRetried), strings.Join(run.emptyRetried, ", "))
+	}
+
+	// Nothing changed, so the dependency manifests and README still apply
+	if previous != nil && len(skipped) == len(filePaths) {
+		for _, entry := range previous.Files {
+			if entry.Kind == fileKindDependency || entry.Kind == fileKindTooling || entry.Kind == fileKindMigration || entry.Kind == fileKindReadme ||
+				entry.Kind == fileKindDocs {
+				manifest.Files = append(manifest.Files, entry)
 			}
 		}
+		a.Log.Infof("✨ Project is up to date!")
+		err = manifest.Write(projectDir)
+		if err != nil {
+			return err
+		}
+		a.writeSummary(spec, projectDir, manifest)
+		return a.runPostHook(spec, projectDir)
+	}
+
+	if err := a.stopBefore(projectDir, run, "dependency manifests"); err != nil {
+		return err
+	}
+	// Make sure the dependency manifests match what the code imports
+	manifestPaths, err := a.GenerateDependencyManifests(spec, projectDir, generatedFiles)
+	if err != nil {
+		return err
+	}
+	for _, manifestPath := range manifestPaths {
+		manifest.Add(manifestPath, fileKindDependency).Provider = a.ProviderFor(manifestPath)
+	}
 
-		fullPath := filepath.Join(projectDir, filePath)
-		err = os.MkdirAll(filepath.Dir(fullPath), 0755)
+	if a.Makefile {
+		if err := a.stopBefore(projectDir, run, "Makefile"); err != nil {
+			return err
+		}
+		makefilePath, err := a.GenerateMakefile(spec, projectDir, generatedFiles)
 		if err != nil {
-			return fmt.Errorf("failed to create directories for %s: %v", filePath, err)
+			return err
 		}
+		if makefilePath != "" {
+			manifest.Add(makefilePath, fileKindTooling).Provider = a.ProviderFor(makefilePath)
+		}
+	}
 
-		// Store generated content for context in subsequent generations
-		generatedFiles[filePath] = fileContent
+	if a.Migrations != "" {
+		if err := a.stopBefore(projectDir, run, "migrations"); err != nil {
+			return err
+		}
+		migrationPaths, err := a.GenerateMigrations(spec, projectDir, a.Migrations, generatedFiles)
+		if err != nil {
+			return err
+		}
+		for _, migrationPath := range migrationPaths {
+			manifest.Add(migrationPath, fileKindMigration).Provider = a.ProviderFor(filepath.Dir(migrationPath))
+		}
+	}
 
-		err = os.WriteFile(fullPath, []byte(fileContent), 0644)
+	if a.CI != "" {
+		if err := a.stopBefore(projectDir, run, "CI workflow"); err != nil {
+			return err
+		}
+		workflowPath, err := a.GenerateCIWorkflow(spec, projectDir, a.CI, generatedFiles)
 		if err != nil {
-			return fmt.Errorf("failed to write file %s: %v", filePath, err)
+			return err
+		}
+		if workflowPath != "" {
+			manifest.Add(workflowPath, fileKindTooling).Provider = a.ProviderFor(workflowPath)
 		}
 	}
 
-	// Generate README.md with context of all generated files
-	var contextBuilder strings.Builder
-	for filePath, content := range generatedFiles {
-		contextBuilder.WriteString(fmt.Sprintf("\n%s:\n```\n%s\n```\n", filePath, content))
+	if a.K8s {
+		if err := a.stopBefore(projectDir, run, "Kubernetes manifests"); err != nil {
+			return err
+		}
+		k8sPaths, err := a.GenerateK8sManifests(spec, projectDir, a.K8sNamespace, a.K8sImage, generatedFiles)
+		if err != nil {
+			return err
+		}
+		for _, k8sPath := range k8sPaths {
+			manifest.Add(k8sPath, fileKindTooling).Provider = a.ProviderFor(k8sDir)
+		}
+	}
+
+	if a.EnvExample {
+		envPath, err := a.GenerateEnvExample(spec, projectDir, generatedFiles)
+		if err != nil {
+			return err
+		}
+		if envPath != "" {
+			manifest.Add(envPath, fileKindTooling)
+		}
+	}
+
+	if a.CommunityFiles {
+		communityPaths, err := a.GenerateCommunityFiles(spec, projectDir, generatedFiles)
+		if err != nil {
+			return err
+		}
+		for _, communityPath := range communityPaths {
+			manifest.Add(communityPath, fileKindTooling)
+		}
+	}
+
+	if a.Diagram {
+		if err := a.stopBefore(projectDir, run, "architecture diagram"); err != nil {
+			return err
+		}
+		diagramFile, err := a.GenerateDiagram(spec, projectDir, generatedFiles)
+		if err != nil {
+			return err
+		}
+		if diagramFile != "" {
+			manifest.Add(diagramFile, fileKindDocs).Provider = a.ProviderFor(diagramFile)
+		}
+	}
+
+	if a.GoBuild {
+		err = a.CheckGoBuild(spec, projectDir, generatedFiles, kept, run.deadline)
+		if err != nil {
+			return err
+		}
+	}
+
+	if a.NoReadme || a.keepExisting(projectDir, "README.md") {
+		return a.finishGeneration(spec, projectDir, manifest, run.failed)
+	}
+
+	if err := a.stopBefore(projectDir, run, "README"); err != nil {
+		return err
+	}
+	done := a.startFile("README.md")
+	readmeContent, err := a.GenerateReadme(spec, generatedFiles)
+	if err != nil {
+		return err
+	}
+
+	err = writeProjectFile(projectDir, "README.md", readmeContent)
+	if err != nil {
+		return err
+	}
+	manifest.Add("README.md", fileKindReadme).Provider = a.ProviderFor("README.md")
+	done()
+
+	return a.finishGeneration(spec, projectDir, manifest, run.failed)
+}
+
+// GenerateReadme generates the README of a project from its spec and the
+// content of its generated files. Files that do not fit the README model's
+// context are summarized first.
+func (a *DevAgent) GenerateReadme(spec *ProjectSpec, generatedFiles map[string]string) (string, error) {
+	var themeLine string
+	if a.Theme != "" {
+		themeLine = fmt.Sprintf("Design Theme: %s\n", a.Theme)
 	}
 
-	readmePrompt := fmt.Sprintf(`Generate a comprehensive README.md for the %s project.
+	// Generate README.md with context of all generated files
+	files, summarized := a.readmeFiles(generatedFiles)
+	render := func(files map[string]string) (string, error) {
+		return fmt.Sprintf(`Generate a comprehensive README.md for the %s project.
 Description: %s
 Framework: %s
 Components: %v
-
+%s
 Project Structure:%s
 
 Include:
@@ -226,109 +886,975 @@ Include:
 3. Usage examples
 4. Component descriptions
 5. Dependencies
-`, spec.Name, spec.Description, spec.Framework, spec.Components, contextBuilder.String())
-
-	resp, err := a.client.CreateChatCompletion(
-		a.ctx,
-		openai.ChatCompletionRequest{
-			Model: openai.GPT4o,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: "Generate a comprehensive README.md file in markdown format.",
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: readmePrompt,
-				},
-			},
-			Temperature: 0.2,
-		},
-	)
+`, spec.Name, spec.Description, spec.Framework, spec.Components, themeLine, describeProjectFiles(files, summarized)), nil
+	}
+	readmePrompt, _ := render(files)
+
+	readmeContent, err := a.chat(chatRequest{
+		Label:       "README.md",
+		Model:       a.ReadmeModel,
+		System:      "Generate a comprehensive README.md file in markdown format.",
+		Prompt:      readmePrompt,
+		Temperature: 0.2,
+		Prune:       pruneContext(files, render),
+	})
 
 	if err != nil {
-		return fmt.Errorf("failed to generate README: %v", err)
+		return "", fmt.Errorf("failed to generate README: %v", err)
 	}
 
-	readmeContent := resp.Choices[0].Message.Content
 	// Remove markdown code blocks if present
 	readmeContent = strings.TrimPrefix(readmeContent, "```markdown")
 	readmeContent = strings.TrimPrefix(readmeContent, "```md")
 	readmeContent = strings.TrimSuffix(readmeContent, "```")
-	readmeContent = strings.TrimSpace(readmeContent)
+	return a.finalizeContent("README.md", strings.TrimSpace(readmeContent)), nil
+}
 
-	err = os.WriteFile(filepath.Join(projectDir, "README.md"), []byte(readmeContent), 0644)
+// finishGeneration writes the manifest, reports files that failed under
+// KeepGoing as a PartialError, and otherwise runs the post-generation hook.
+func (a *DevAgent) finishGeneration(spec *ProjectSpec, projectDir string, manifest *Manifest, failed []string) error {
+	err := manifest.Write(projectDir)
 	if err != nil {
-		return fmt.Errorf("failed to write README: %v", err)
+		return err
 	}
 
-	fmt.Println("✨ Project generated successfully!")
-	return nil
-}
+	if oversized := a.takeOversized(); len(oversized) > 0 {
+		a.Log.Warnf("Truncated %d oversized file(s): %s", len(oversized), strings.Join(oversized, ", "))
+	}
 
-func main() {
-	apiKey := flag.String("api-key", "", "OpenAI API Key")
-	flag.Parse()
+	if len(failed) > 0 {
+		a.Log.Infof("📦 Generated %d file(s); %d failed", len(manifest.Files), len(failed))
+		a.writeSummary(spec, projectDir, manifest)
+		return &PartialError{Files: failed}
+	}
 
-	if *apiKey == "" {
-		*apiKey = os.Getenv("OPENAI_API_KEY")
-		if *apiKey == "" {
-			fmt.Println("Please provide an API key via -api-key flag or OPENAI_API_KEY environment variable")
-			os.Exit(1)
-		}
+	if a.ProjectCacheDir != "" {
+		a.cacheProject(spec, projectDir, manifest)
 	}
 
-	agent := NewDevAgent(*apiKey)
+	a.Log.Infof("✨ Project generated successfully!")
+	a.writeSummary(spec, projectDir, manifest)
+	return a.runPostHook(spec, projectDir)
+}
 
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Println("🧞 AI Project Generator (Type 'exit' to quit)")
-	fmt.Println("-------------------------------------------")
-	fmt.Println("I'm your project assistant! Describe what you want to build and I'll make it happen.")
-	fmt.Println("Example: 'Create a React dashboard with authentication, dark mode, and real-time charts'")
-	fmt.Println("Let's get started!")
-	fmt.Println()
+// wrapCodePrompt adds the configured prefix and suffix to a code
+// generation prompt.
+func (a *DevAgent) wrapCodePrompt(prompt string) string {
+	if a.PromptPrefix != "" {
+		prompt = a.PromptPrefix + "\n\n" + prompt
+	}
+	if a.PromptSuffix != "" {
+		prompt = prompt + "\n\n" + a.PromptSuffix
+	}
+	return prompt
+}
 
-	for {
-		fmt.Print("Project description: ")
-		input, err := reader.ReadString('\n')
-		if err != nil {
-			fmt.Printf("Error reading input: %v\n", err)
-			continue
-		}
+// Exit codes of a run that generates a project; see the README.
+const (
+	exitFailure = 1 // generation failed or was stopped
+	exitPartial = 2 // under -keep-going, some files failed and the rest were generated
+)
 
-		input = strings.TrimSpace(input)
-		if input == "exit" {
-			break
+// exitCode returns the exit code for a failed generation.
+func exitCode(err error) int {
+	var partial *PartialError
+	if errors.As(err, &partial) {
+		return exitPartial
+	}
+	return exitFailure
+}
+
+// parseModelJSON decodes a JSON reply of the model into v. A surrounding
+// code fence is removed first, unless StrictJSON requires the reply to be
+// exactly valid JSON.
+func (a *DevAgent) parseModelJSON(content string, v interface{}) error {
+	if !a.StrictJSON {
+		content = stripJSONFences(content)
+	}
+	return json.Unmarshal([]byte(content), v)
+}
+
+// stripJSONFences removes a surrounding ```json markdown code block from a
+// model response.
+func stripJSONFences(content string) string {
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimSuffix(content, "```")
+	return strings.TrimSpace(content)
+}
+
+// reviewSpec prints the model's critique of the spec and returns the
+// revised spec if the user chooses to apply the suggested changes.
+func reviewSpec(agent *DevAgent, spec *ProjectSpec, reader *bufio.Reader, specFormat string) *ProjectSpec {
+	fmt.Fprintln(stdout, "\n🔎 Reviewing specification...")
+	review, err := agent.ReviewSpec(spec)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error reviewing project specification: %v\n", err)
+		return spec
+	}
+
+	fmt.Fprintln(stdout, "\n📝 Review:")
+	fmt.Fprintln(stdout, review.Summary)
+	for _, issue := range review.Issues {
+		fmt.Fprintf(stdout, "  - %s\n", issue)
+	}
+
+	if review.RevisedSpec == nil || len(review.RevisedSpec.Files) == 0 {
+		return spec
+	}
+
+	fmt.Fprint(stdout, "\nApply the suggested spec changes? (y/n): ")
+	apply, _ := reader.ReadString('\n')
+	if strings.TrimSpace(strings.ToLower(apply)) != "y" {
+		return spec
+	}
+
+	fmt.Fprintln(stdout, "\n📋 Revised Project Specification:")
+	fmt.Fprintln(stdout, formatSpec(review.RevisedSpec, specFormat))
+	return review.RevisedSpec
+}
+
+// confirmLargeSpec asks before generating a spec with more than maxFiles
+// files. Without a terminal to ask on, large specs are refused.
+func confirmLargeSpec(agent *DevAgent, spec *ProjectSpec, maxFiles int, reader *bufio.Reader) bool {
+	if maxFiles <= 0 || len(spec.Files) <= maxFiles {
+		return true
+	}
+
+	estimate := agent.EstimateSpecCost(spec)
+	approx := ""
+	if !estimate.Known {
+		approx = " (some model prices unknown)"
+	}
+	fmt.Fprintf(stdout, "\n⚠️  The spec has %d files, more than the limit of %d.\n", len(spec.Files), maxFiles)
+	fmt.Fprintf(stdout, "   Estimated usage: ~%d tokens, ~$%.2f%s\n", estimate.PromptTokens+estimate.CompletionTokens, estimate.Cost, approx)
+
+	if !isInteractive() {
+		fmt.Fprintln(stdout, "Refusing to generate a large spec in non-interactive mode; raise -max-files to allow it")
+		return false
+	}
+
+	fmt.Fprint(stdout, "Generate it anyway? (y/n): ")
+	confirm, _ := reader.ReadString('\n')
+	return strings.TrimSpace(strings.ToLower(confirm)) == "y"
+}
+
+// printCostEstimate prints the -dry-run-cost estimate without calling the
+// API. A spec is estimated file by file from its descriptions; a project
+// description only allows estimating the planning call.
+func printCostEstimate(agent *DevAgent, spec *ProjectSpec, prompt string) {
+	var low, high CostEstimate
+	fmt.Fprintln(stdout, "💰 Estimated cost (offline, token counts are approximate):")
+	if spec != nil {
+		low, high = agent.EstimateSpecCostRange(spec)
+		fmt.Fprintf(stdout, "   %d files with %s, README with %s\n", len(spec.Files), agent.CodeModel, agent.ReadmeModel)
+	} else {
+		low, high = agent.EstimatePromptCost(prompt)
+		fmt.Fprintf(stdout, "   Project spec with %s (use -spec-file to estimate the generated files)\n", agent.SpecModel)
+	}
+	fmt.Fprintf(stdout, "   Prompt tokens:     ~%d - %d\n", low.PromptTokens, high.PromptTokens)
+	fmt.Fprintf(stdout, "   Completion tokens: ~%d - %d\n", low.CompletionTokens, high.CompletionTokens)
+	if !low.Known {
+		fmt.Fprintln(stdout, "   Cost: unknown, some model prices are not listed")
+		return
+	}
+	fmt.Fprintf(stdout, "   Cost:              ~$%.2f - $%.2f\n", low.Cost, high.Cost)
+}
+
+// resolveModels checks that the configured models are available. For a
+// missing model the closest alternatives are shown, and on a terminal the
+// user can switch to the closest one. It returns false if a model is still
+// missing.
+func resolveModels(agent *DevAgent, reader *bufio.Reader) bool {
+	for _, missing := range agent.CheckModels() {
+		fmt.Fprintf(stdout, "⚠️  Model %s for the %s phase is not available with the configured provider.\n", missing.Model, missing.Phase)
+		if len(missing.Suggestions) == 0 {
+			return false
+		}
+		fmt.Fprintf(stdout, "   Available models that come closest: %s\n", strings.Join(missing.Suggestions, ", "))
+		if !isInteractive() {
+			fmt.Fprintln(stdout, "   Choose one with -models, e.g. -models "+missing.Phase+"="+missing.Suggestions[0])
+			return false
 		}
 
-		if input == "" {
-			continue
+		fmt.Fprintf(stdout, "Use %s instead? (y/n): ", missing.Suggestions[0])
+		answer, _ := reader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(answer)) != "y" {
+			return false
 		}
+		agent.SetModels(map[string]string{missing.Phase: missing.Suggestions[0]})
+	}
+	return true
+}
 
-		// Generate project specification
-		spec, err := agent.GenerateProjectSpec(input)
+func main() {
+	apiKey := flag.String("api-key", "", "OpenAI API Key")
+	isolate := flag.Bool("isolate", false, "Generate into a new temporary directory and print its path instead of writing below -output-dir")
+	gitBranch := flag.String("git-branch", "", "Check out this branch, creating it if needed, in the git repository holding the output directory before generating; switching branches needs a clean working tree")
+	gitCommit := flag.Bool("git-commit", false, "With -git-branch, commit the generated files to the new branch")
+	changelog := flag.Bool("changelog", false, "With -git-branch, add an entry describing the added and changed files to the repository's CHANGELOG.md, creating it if needed")
+	isolateCleanup := flag.Bool("isolate-cleanup", false, "Remove the -isolate directory after generation and the -post-hook, e.g. when the hook copies the project elsewhere")
+	proxy := flag.String("proxy", "", "Proxy URL for API requests (http, https or socks5); defaults to HTTPS_PROXY/HTTP_PROXY from the environment")
+	caCert := flag.String("ca-cert", "", "PEM file with extra CA certificates to trust for API requests, e.g. a TLS-intercepting proxy's")
+	providers := flag.String("providers", "openai", "Comma-separated providers to fall back through ("+strings.Join(providerNames, ", ")+"); append =model to force a model, e.g. ollama=llama3.1")
+	configFile := flag.String("config", "", "JSON config file")
+	configDump := flag.Bool("config-dump", false, "Print the configuration resolved from flags, the config file and defaults as JSON, with the API key redacted, and exit")
+	yes := flag.Bool("yes", false, "Generate without asking for confirmation; without a terminal, generation only proceeds with -yes")
+	componentsOnly := flag.Bool("components-only", false, "Only outline the components and description of the project given as arguments, without planning or generating files")
+	enhancePrompt := flag.Bool("enhance-prompt", false, "Expand the project description into detailed requirements with a cheap model, shown for approval, before planning the project")
+	models := flag.String("models", "", "Per-phase models, e.g. spec=gpt-4o-mini,code=gpt-4-turbo,readme=gpt-4o; code.<language>=model sets the model for one language, e.g. code.typescript=gpt-4o; enhance=model sets the -enhance-prompt model and summary=model the model summarizing files for the README")
+	keepGoing := flag.Bool("keep-going", false, "Continue with the remaining files when a file fails to generate or cannot be written, and exit with status 2 if any failed")
+	withTests := flag.Bool("with-tests", false, "Generate a test file alongside each source file")
+	projectName := flag.String("name", "", "Project name to use instead of the one the model suggests, for the project directory and README")
+	outputDir := flag.String("output-dir", "", "Directory to generate projects in, e.g. a package path inside a monorepo")
+	onExists := flag.String("on-exists", onExistsMerge, "What to do when the project directory already exists: "+strings.Join(onExistsPolicies, ", ")+" (the default will change to error in a future major version)")
+	importBase := flag.String("import-base", "", "Module path of the repository the output directory belongs to, e.g. github.com/org/monorepo")
+	retryStatuses := flag.String("retry-statuses", defaultRetryStatuses, "Comma-separated HTTP status codes and classes such as 5xx that are retried as transient errors, e.g. 408,429,5xx")
+	maxRetries := flag.Int("max-retries", defaultMaxRetries, "How often to retry an API call after a transient error such as a rate limit or timeout")
+	fixTemperatureStep := flag.Float64("fix-temperature-step", defaultFixTemperatureStep, "How much to raise the temperature on each fix attempt, so it does not repeat the rejected output (0 keeps it constant)")
+	fixAttempts := flag.Int("fix-attempts", defaultFixAttempts, "How often to send output that fails validation (-fix-extensions, -go-build) back to the model; each attempt has its own -max-retries")
+	theme := flag.String("theme", "", "Design theme for frontend files, e.g. dark, minimal or material")
+	maxFiles := flag.Int("max-files", 50, "Ask for confirmation before generating specs with more files than this (0 disables the check)")
+	batchFiles := flag.Int("batch-files", 0, "Generate up to N small files with a single request to cut per-request overhead (0 or 1 generates each file on its own; -code-template and themed UI files are never batched)")
+	contextWindow := flag.Int("context-window", 0, "Include only the N most recently generated files as context, a cheaper alternative to -context-top-k (0 includes all)")
+	contextTopK := flag.Int("context-top-k", 0, "Include only the K previous files with the most similar descriptions as context, using the embeddings API (0 includes all)")
+	chunkLarge := flag.Bool("chunk-large", false, "Generate files the spec marks as large section by section (uses more API calls)")
+	testsOnly := flag.String("generate-tests-only", "", "Generate tests for the source files without tests of the existing project in this directory, leaving other files untouched; -spec-file adds the project description")
+	regenReadme := flag.String("regen-readme", "", "Regenerate only the README.md of the existing project in this directory from its files on disk; -spec-file adds the project description")
+	watch := flag.Bool("watch", false, "Keep watching -spec-file and regenerate the files whose descriptions changed after each save")
+	specFormat := flag.String("spec-format", "json", "How the planned spec is shown before generation: "+strings.Join(specFormats, " or ")+" (files grouped by directory)")
+	specFile := flag.String("spec-file", "", "Generate the project from a spec JSON file instead of a description; - reads the spec from stdin, as does piping a spec without arguments")
+	projectCacheDir := flag.String("project-cache-dir", "", "Cache generated projects in this directory, keyed by the hash of the spec and options, and restore an identical spec with the same options from it without API calls")
+	stdoutFile := flag.String("file", "", "With -spec-file, generate only this file of the spec and write it to stdout")
+	patchFile := flag.String("patch", "", "RFC 6902 JSON Patch file applied to the -spec-file spec before generation")
+	openAPIFile := flag.String("openapi", "", "Generate a server implementing this OpenAPI/Swagger file (JSON or YAML); extra arguments are added as requirements")
+	skipExisting := flag.Bool("skip-existing", false, "Only generate files that do not exist yet, keeping existing files untouched and using them as context")
+	incremental := flag.Bool("incremental", false, "Only regenerate files whose spec description changed since the last run")
+	styleGuide := flag.String("style-guide", "", "Style guide file, e.g. your organization's coding conventions, added to the system prompt of every generated file; long guides are truncated to fit the code model's context")
+	var styleExamples stringList
+	flag.Var(&styleExamples, "style-example", "Exemplar file whose coding style generated code should follow (repeatable); it is shown to the model, not copied")
+	codeSystemFile := flag.String("code-system-file", "", "File with the system prompt for code generation; lines like '@include other.md' are expanded")
+	profile := flag.String("profile", "", "Prompt profile, e.g. prototype for a quick draft or production for robust output; more can be defined in the config file")
+	specTemplate := flag.String("spec-template", "", "Go text/template file for the spec planning system prompt; {{.Prompt}} is the project description")
+	codeTemplate := flag.String("code-template", "", "Go text/template file for the file generation prompt, with {{.Spec}}, {{.FilePath}}, {{.Description}}, {{.Context}} and {{.Files}}")
+	fixExtensions := flag.Bool("fix-extensions", false, "Re-plan the spec when its files do not match the declared framework")
+	promptPrefix := flag.String("prompt-prefix", "", "Text added before every code generation prompt")
+	promptSuffix := flag.String("prompt-suffix", "", "Text added after every code generation prompt")
+	maxRuntime := flag.Duration("max-runtime", 0, "Stop generating after this long, e.g. 10m, once the files in progress are finished, and exit with an error (0 means no limit)")
+	resumeFrom := flag.String("resume-from", "", "Regenerate from this spec file onward, reusing earlier files on disk as context")
+	redact := flag.Bool("redact-secrets", false, "Replace API keys, passwords and other secret-looking strings in generated files with placeholders")
+	concurrency := flag.Int("concurrency", 1, "Number of files to generate at the same time")
+	maxPerDir := flag.Int("max-concurrency-per-dir", defaultMaxConcurrencyPerDir, "Maximum number of files generated at the same time within one directory (0 means no limit)")
+	formatFiles := flag.Bool("format", false, "Format generated files with gofmt, prettier, black or rustfmt where available")
+	maxFileSize := flag.Int("max-file-size", 0, "Truncate generated files larger than this many bytes, with a marker at the cut (0 means no limit)")
+	previewLines := flag.Int("preview-lines", 0, "Print the first N lines of each generated file (0 disables the preview)")
+	extrasFlag := flag.String("extras", "", "Comma-separated optional outputs to generate, in addition to those of their own flags: "+strings.Join(extraNames(), ", ")+"; README.md is left out unless listed (help lists them)")
+	makefile := flag.Bool("makefile", false, "Generate a Makefile with build, test, run and lint targets for the project")
+	migrations := flag.String("migrations", "", "Generate ordered database migrations for projects that use a database, for this tool: "+strings.Join(migrationToolNames(), ", "))
+	k8s := flag.Bool("k8s", false, "Generate Kubernetes Deployment, Service and ConfigMap manifests under k8s/, using the code and any Dockerfile as context")
+	k8sNamespace := flag.String("k8s-namespace", defaultK8sNamespace, "Namespace of the manifests generated with -k8s")
+	k8sImage := flag.String("k8s-image", "", "Container image run by the manifests generated with -k8s (default <project name>:latest)")
+	ci := flag.String("ci", "", "Generate a CI workflow that builds and tests the project for this provider: "+strings.Join(ciProviderNames(), " or "))
+	goWorkspace := flag.Bool("go-workspace", false, "When the spec plans several Go modules, generate a go.mod per module and a go.work that ties them together")
+	diagram := flag.Bool("diagram", false, "Generate docs/architecture.md with a Mermaid diagram of the project's components and their relationships")
+	communityFilesFlag := flag.Bool("community-files", false, "Add CONTRIBUTING.md, CODE_OF_CONDUCT.md, SECURITY.md and GitHub issue and pull request templates, rendered from templates without model calls")
+	envExampleFlag := flag.Bool("env-example", false, "Generate a .env.example listing the environment variables the code reads")
+	goBuild := flag.Bool("go-build", false, "Run go build on generated Go projects and send compile errors back to the model up to -fix-attempts times")
+	review := flag.Bool("review", false, "Ask the model to critique the project spec before generating")
+	contextDir := flag.String("context-dir", "", "Directory of existing files, e.g. shared types and utilities, given to every generated file as read-only context")
+	strict := flag.Bool("strict", false, "Fail when the model misbehaves, e.g. returns an empty file, instead of retrying or repairing its reply; implies -strict-json")
+	strictJSON := flag.Bool("strict-json", false, "Fail on JSON replies that are not exactly valid JSON instead of removing code fences or continuing truncated specs")
+	headerFile := flag.String("header-file", "", "Add the text of this file, such as a license notice, to the top of every generated file as a comment in the file's syntax")
+	snippetsDir := flag.String("snippets-dir", "", "Directory of shared boilerplate snippets, such as license headers, that files reference instead of regenerating")
+	listFiles := flag.Bool("list-files", false, "Print the files of -spec-file with their descriptions without generating anything")
+	listFormat := flag.String("list-format", "table", "Output format of -list-files: "+strings.Join(listFormats, " or "))
+	dryRunFiles := flag.Bool("dry-run-files", false, "Create the project directory with a stub for each file of -spec-file, holding its description as a TODO comment, without calling the API")
+	dryRunCost := flag.Bool("dry-run-cost", false, "Print an offline cost estimate for -spec-file, -openapi or a description given as arguments, without calling the API")
+	summaryFile := flag.String("summary-file", "", "Write a markdown summary of the generated project, including token usage and cost, to this file")
+	postHook := flag.String("post-hook", "", "Shell command to run in the project directory after generation; ASHUTOSH_PROJECT_NAME and ASHUTOSH_PROJECT_DIR are set")
+	postHookFail := flag.Bool("post-hook-fail", false, "Fail the run when the -post-hook command exits with an error")
+	confirmEach := flag.Bool("confirm-each", false, "Show each generated file and ask whether to write, regenerate or skip it (interactive only)")
+	selfTest := flag.Bool("selftest", false, "Generate a tiny fixed project in a temporary directory to check that the API key and models work")
+	skipModelCheck := flag.Bool("skip-model-check", false, "Do not check that the configured models are available before generating")
+	reasoningEffort := flag.String("reasoning-effort", "", "Reasoning effort for models that support it, such as o-series models: "+strings.Join(reasoningEfforts, ", "))
+	verbosity := flag.String("verbosity", "", "Output verbosity for models that support it, such as gpt-5: "+strings.Join(verbosityLevels, ", "))
+	serviceTier := flag.String("service-tier", "", "Processing tier for OpenAI models: "+strings.Join(serviceTiers, ", "))
+	socketPath := flag.String("socket", "", "Stream generation events as JSON lines to clients of a Unix domain socket at this path, e.g. an editor plugin")
+	errorsFile := flag.String("quiet-errors-to-file", "", "Also append every error, with a timestamp, to this file to review after a long run; errors are still shown")
+	logOutput := flag.String("log-output", "", "Send log messages as structured records to "+logOutputs+" instead of the console")
+	verbose := flag.Bool("verbose", false, "Show detailed output such as prompt token estimates")
+	color := flag.String("color", colorAuto, "Color status output: auto (only on a terminal without NO_COLOR set), always or never")
+	noEmoji := flag.Bool("no-emoji", false, "Print plain ASCII tags such as [INFO] instead of emoji (the default when the locale is set to one without UTF-8)")
+	flag.Parse()
+
+	for _, param := range []struct {
+		name   string
+		value  string
+		values []string
+	}{
+		{"reasoning-effort", *reasoningEffort, reasoningEfforts},
+		{"verbosity", *verbosity, verbosityLevels},
+		{"service-tier", *serviceTier, serviceTiers},
+	} {
+		if param.value != "" && indexOf(param.values, param.value) < 0 {
+			fmt.Fprintf(os.Stdout, "Invalid -%s value %q: use one of %s\n", param.name, param.value, strings.Join(param.values, ", "))
+			os.Exit(1)
+		}
+	}
+	if indexOf(colorModes, *color) < 0 {
+		fmt.Fprintf(os.Stdout, "Invalid -color value %q: use one of %s\n", *color, strings.Join(colorModes, ", "))
+		os.Exit(1)
+	}
+	stdout = consoleWriter(os.Stdout, *noEmoji || !unicodeTerminal(), *color)
+	stderr = consoleWriter(os.Stderr, *noEmoji || !unicodeTerminal(), *color)
+
+	if *apiKey == "" {
+		*apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+
+	if !isOnExistsPolicy(*onExists) {
+		fmt.Fprintf(stdout, "Invalid -on-exists value %q: use one of %s\n", *onExists, strings.Join(onExistsPolicies, ", "))
+		os.Exit(1)
+	}
+
+	if _, ok := ciWorkflows[*ci]; *ci != "" && !ok {
+		fmt.Fprintf(stdout, "Invalid -ci value %q: use one of %s\n", *ci, strings.Join(ciProviderNames(), ", "))
+		os.Exit(1)
+	}
+
+	if _, ok := migrationTools[*migrations]; *migrations != "" && !ok {
+		fmt.Fprintf(stdout, "Invalid -migrations value %q: use one of %s\n", *migrations, strings.Join(migrationToolNames(), ", "))
+		os.Exit(1)
+	}
+
+	if indexOf(specFormats, *specFormat) < 0 {
+		fmt.Fprintf(stdout, "Invalid -spec-format value %q: use one of %s\n", *specFormat, strings.Join(specFormats, ", "))
+		os.Exit(1)
+	}
+
+	if *isolateCleanup && !*isolate {
+		fmt.Fprintln(stdout, "Error: -isolate-cleanup needs -isolate")
+		os.Exit(1)
+	}
+	if *fixTemperatureStep < 0 {
+		fmt.Fprintln(stdout, "Error: -fix-temperature-step cannot be negative")
+		os.Exit(1)
+	}
+	if err := validateK8sNamespace(*k8sNamespace); err != nil {
+		fmt.Fprintf(stdout, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *extrasFlag == "help" {
+		fmt.Fprintf(stdout, "Available extras:\n%s", describeExtras())
+		return
+	}
+	var selectedExtras map[string]bool
+	if *extrasFlag != "" {
+		var err error
+		selectedExtras, err = parseExtras(*extrasFlag)
 		if err != nil {
-			fmt.Printf("Error generating project specification: %v\n", err)
-			continue
+			fmt.Fprintf(stdout, "Invalid -extras value %q: %v\nAvailable extras:\n%s", *extrasFlag, err, describeExtras())
+			os.Exit(1)
+		}
+	}
+	if *contextWindow < 0 {
+		fmt.Fprintln(stdout, "Error: -context-window cannot be negative")
+		os.Exit(1)
+	}
+	if *batchFiles < 0 {
+		fmt.Fprintln(stdout, "Error: -batch-files cannot be negative")
+		os.Exit(1)
+	}
+	if *gitCommit && *gitBranch == "" {
+		fmt.Fprintln(stdout, "Error: -git-commit needs -git-branch")
+		os.Exit(1)
+	}
+	if *changelog && *gitBranch == "" {
+		fmt.Fprintln(stdout, "Error: -changelog needs -git-branch")
+		os.Exit(1)
+	}
+	if *gitBranch != "" && *isolate {
+		fmt.Fprintln(stdout, "Error: -git-branch cannot be combined with -isolate")
+		os.Exit(1)
+	}
+
+	httpClient, err := newHTTPClient(*proxy, *caCert)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error configuring HTTP client: %v\n", err)
+		os.Exit(1)
+	}
+
+	agent := NewDevAgentWithHTTPClient(*apiKey, httpClient)
+	// -file writes the generated file to stdout, so every message, including
+	// warnings about the options below, goes to stderr
+	if *stdoutFile != "" {
+		agent.Log.Out = stderr
+	}
+	// A cost estimate, file list or stub layout runs offline and needs no
+	// credentials; a config dump shows the providers only if they can be
+	// set up
+	if !*dryRunCost && !*listFiles && !*dryRunFiles {
+		providerList, err := parseProviders(*providers, *apiKey, httpClient)
+		if err != nil && !*configDump {
+			fmt.Fprintf(stdout, "Error configuring providers: %v\n", err)
+			os.Exit(1)
+		}
+		agent.Providers = providerList
+	}
+	agent.KeepGoing = *keepGoing
+	agent.WithTests = *withTests
+	agent.OutputDir = *outputDir
+	if *projectName != "" {
+		agent.Name = safeProjectName(*projectName)
+	}
+	agent.OnExists = *onExists
+	agent.Isolate = *isolate
+	agent.GitBranch = *gitBranch
+	agent.GitCommit = *gitCommit
+	agent.Changelog = *changelog
+	agent.RemoveIsolated = *isolateCleanup
+	agent.ImportBase = *importBase
+	agent.MaxRetries = *maxRetries
+	agent.RetryStatuses, err = parseRetryStatuses(*retryStatuses)
+	if err != nil {
+		fmt.Fprintf(stdout, "Invalid -retry-statuses value %q: %v\n", *retryStatuses, err)
+		os.Exit(1)
+	}
+	agent.FixAttempts = *fixAttempts
+	agent.FixTemperatureStep = float32(*fixTemperatureStep)
+	agent.Theme = *theme
+	agent.ChunkLargeFiles = *chunkLarge
+	agent.ContextTopK = *contextTopK
+	agent.ContextWindow = *contextWindow
+	agent.BatchFiles = *batchFiles
+	agent.Incremental = *incremental
+	agent.SkipExisting = *skipExisting
+	agent.ResumeFrom = *resumeFrom
+	agent.MaxRuntime = *maxRuntime
+	agent.RedactSecrets = *redact
+	agent.StrictJSON = *strictJSON || *strict
+	agent.Strict = *strict
+	if *headerFile != "" {
+		agent.Header, err = loadHeader(*headerFile)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error loading header: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	agent.Concurrency = *concurrency
+	agent.MaxConcurrencyPerDir = *maxPerDir
+	agent.PreviewLines = *previewLines
+	agent.MaxFileSize = *maxFileSize
+	agent.Format = *formatFiles
+	agent.Makefile = *makefile
+	agent.CI = *ci
+	agent.Migrations = *migrations
+	agent.SummaryFile = *summaryFile
+	agent.EnvExample = *envExampleFlag
+	agent.Diagram = *diagram
+	agent.K8s = *k8s
+	agent.K8sNamespace = *k8sNamespace
+	agent.K8sImage = *k8sImage
+	agent.GoWorkspace = *goWorkspace
+	agent.ReasoningEffort = *reasoningEffort
+	agent.Verbosity = *verbosity
+	agent.ServiceTier = *serviceTier
+	agent.ProjectCacheDir = *projectCacheDir
+	agent.CommunityFiles = *communityFilesFlag
+	if selectedExtras != nil {
+		agent.applyExtras(selectedExtras)
+	}
+	agent.PostHook = *postHook
+	agent.PostHookFailOnError = *postHookFail
+	agent.GoBuild = *goBuild
+	agent.FixExtensions = *fixExtensions
+	agent.PromptPrefix = *promptPrefix
+	agent.PromptSuffix = *promptSuffix
+
+	confirmDefault := "n"
+	if *configFile != "" {
+		config, err := loadConfig(*configFile)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+		config.Apply(agent)
+		if config.ConfirmDefault != "" {
+			confirmDefault = config.ConfirmDefault
+		}
+	}
+	agent.Log.Verbose = *verbose
+	if *logOutput != "" {
+		agent.Log.Sink, err = openLogSink(*logOutput)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error opening log output: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *socketPath != "" {
+		socket, err := listenSocket(*socketPath)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer socket.Close()
+		agent.Observer = multiObserver{agent.Observer, socket}
+	}
+	if *errorsFile != "" {
+		file, err := os.OpenFile(*errorsFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error: %v\n", describeFSError("open errors file", *errorsFile, err))
+			os.Exit(1)
+		}
+		defer file.Close()
+		agent.Log.ErrorLog = file
+	}
+
+	if *profile != "" {
+		if _, ok := agent.Profiles[*profile]; !ok {
+			fmt.Fprintf(stdout, "Unknown -profile %q (available profiles: %s)\n", *profile, strings.Join(agent.profileNames(), ", "))
+			os.Exit(1)
+		}
+		agent.Profile = *profile
+	}
+
+	if *codeSystemFile != "" {
+		prompt, err := loadPromptFile(*codeSystemFile)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error loading code system prompt: %v\n", err)
+			os.Exit(1)
+		}
+		agent.CodeSystemPrompt = prompt
+	}
+
+	if len(styleExamples) > 0 {
+		examples, err := loadStyleExamples(styleExamples)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error loading style examples: %v\n", err)
+			os.Exit(1)
+		}
+		agent.StyleExamples = examples
+	}
+
+	if *specTemplate != "" {
+		tmpl, err := loadPromptTemplate(*specTemplate, "spec", sampleSpecData)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error loading spec template: %v\n", err)
+			os.Exit(1)
+		}
+		agent.SpecTemplate = tmpl
+	}
+
+	if *codeTemplate != "" {
+		tmpl, err := loadPromptTemplate(*codeTemplate, "code", sampleCodeData)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error loading code template: %v\n", err)
+			os.Exit(1)
+		}
+		agent.CodeTemplate = tmpl
+	}
+
+	if *contextDir != "" {
+		files, err := loadContextDir(*contextDir)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error loading context directory: %v\n", err)
+			os.Exit(1)
+		}
+		agent.BaseContext = files
+	}
+
+	if *snippetsDir != "" {
+		snippets, err := loadSnippets(*snippetsDir)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error loading snippets: %v\n", err)
+			os.Exit(1)
+		}
+		agent.Snippets = snippets
+	}
+
+	if *models != "" {
+		phaseModels, err := parseModelMap(*models)
+		if err != nil {
+			fmt.Fprintf(stdout, "Invalid -models value: %v\n", err)
+			os.Exit(1)
+		}
+		agent.SetModels(phaseModels)
+	}
+
+	// The guide is fitted to the code model, so it is loaded once models are set
+	if *styleGuide != "" {
+		guide, truncated, err := loadStyleGuide(*styleGuide, agent.CodeModel)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error loading style guide: %v\n", err)
+			os.Exit(1)
+		}
+		if truncated {
+			agent.Log.Warnf("The style guide %s is too long for %s and was truncated to ~%d tokens", *styleGuide, agent.CodeModel, estimateTokens(agent.CodeModel, guide))
+		}
+		agent.StyleGuide = guide
+	}
+
+	if *configDump {
+		if err := agent.dumpConfig(os.Stdout, *apiKey, confirmDefault); err != nil {
+			fmt.Fprintf(stdout, "Error: %v\n", err)
+			os.Exit(1)
 		}
+		return
+	}
 
-		// Show specification and ask for confirmation
-		specJSON, _ := json.MarshalIndent(spec, "", "  ")
-		fmt.Println("\n📋 Project Specification:")
-		fmt.Println(string(specJSON))
-		fmt.Print("\nProceed with generation? (y/n): ")
+	if *patchFile != "" && *specFile == "" {
+		fmt.Fprintln(stdout, "Error: -patch needs -spec-file")
+		os.Exit(1)
+	}
 
-		confirm, _ := reader.ReadString('\n')
-		confirm = strings.TrimSpace(strings.ToLower(confirm))
+	reader := bufio.NewReader(os.Stdin)
 
-		if confirm == "y" {
-			err = agent.GenerateCode(spec)
+	// A spec piped on stdin, e.g. from jq, is used as with -spec-file -
+	if *specFile == "" && *openAPIFile == "" && flag.NArg() == 0 && !isInteractive() && pipedSpec(reader) {
+		*specFile = "-"
+	}
+	if *specFile == "-" && *watch {
+		fmt.Fprintln(stdout, "Error: -watch needs a spec file, not stdin")
+		os.Exit(1)
+	}
+
+	// loadSpec reads -spec-file, or stdin for "-", and applies -patch to it
+	loadSpec := func() (*ProjectSpec, error) {
+		var spec *ProjectSpec
+		var err error
+		if *specFile == "-" {
+			spec, err = readSpecStdin(reader)
+		} else {
+			spec, err = loadSpecFile(*specFile)
+		}
+		if err != nil || *patchFile == "" {
+			return spec, err
+		}
+		return agent.ApplySpecPatch(spec, *patchFile)
+	}
+	if *confirmEach {
+		if isInteractive() {
+			agent.ReviewFile = promptFileReview(reader)
+			// Reviews are answered one file at a time
+			agent.Concurrency = 1
+		} else {
+			agent.Log.Warnf("Ignoring -confirm-each: stdin is not a terminal")
+		}
+	}
+
+	// List the spec's files on stdout for reading or piping; status
+	// messages such as applied patches go to stderr
+	if *listFiles {
+		agent.Log.Out = stderr
+		if *specFile == "" {
+			fmt.Fprintln(stderr, "Error: -list-files needs -spec-file")
+			os.Exit(1)
+		}
+		spec, err := loadSpec()
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading project specification: %v\n", err)
+			os.Exit(1)
+		}
+		if err := printFileList(os.Stdout, spec, *listFormat); err != nil {
+			fmt.Fprintf(stderr, "Error listing files: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *dryRunCost {
+		var spec *ProjectSpec
+		var err error
+		prompt := strings.Join(flag.Args(), " ")
+		switch {
+		case *specFile != "":
+			spec, err = loadSpec()
+		case *openAPIFile != "":
+			var doc map[string]interface{}
+			doc, err = loadOpenAPI(*openAPIFile)
+			if err == nil {
+				prompt = openAPIPrompt(doc, prompt)
+			}
+		case prompt == "":
+			err = fmt.Errorf("-dry-run-cost needs -spec-file, -openapi or a project description as arguments")
+		}
+		if err != nil {
+			fmt.Fprintf(stdout, "Error estimating cost: %v\n", err)
+			os.Exit(1)
+		}
+		printCostEstimate(agent, spec, prompt)
+		return
+	}
+
+	if *dryRunFiles {
+		if *specFile == "" {
+			fmt.Fprintln(stdout, "Error: -dry-run-files needs -spec-file")
+			os.Exit(1)
+		}
+		spec, err := loadSpec()
+		if err != nil {
+			fmt.Fprintf(stdout, "Error loading project specification: %v\n", err)
+			os.Exit(1)
+		}
+		if _, err := agent.WriteStubs(spec); err != nil {
+			fmt.Fprintf(stdout, "Error writing stub files: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Stdout mode stays quiet and reports a missing model as an API error
+	if !*skipModelCheck && *stdoutFile == "" && !resolveModels(agent, reader) {
+		os.Exit(1)
+	}
+
+	if *selfTest {
+		if err := agent.SelfTest(); err != nil {
+			agent.Log.Errorf("%v", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Print a single file for use in pipelines; status messages went to
+	// stderr above
+	if *stdoutFile != "" {
+		if *specFile == "" {
+			fmt.Fprintln(stderr, "Error: -file needs -spec-file")
+			os.Exit(1)
+		}
+		spec, err := loadSpec()
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading project specification: %v\n", err)
+			os.Exit(1)
+		}
+		content, err := agent.GenerateFile(spec, *stdoutFile, agent.BaseContext)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error generating %s: %v\n", *stdoutFile, err)
+			os.Exit(1)
+		}
+		content = agent.finalizeContent(*stdoutFile, content)
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		fmt.Fprint(os.Stdout, content)
+		return
+	}
+
+	if *regenReadme != "" {
+		var spec *ProjectSpec
+		if *specFile != "" {
+			var err error
+			spec, err = loadSpec()
 			if err != nil {
-				fmt.Printf("Error generating project: %v\n", err)
-				continue
+				fmt.Fprintf(stdout, "Error loading project specification: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		err := agent.RegenerateReadme(*regenReadme, spec)
+		fmt.Fprintf(stdout, "📊 Usage: %s\n", agent.Usage.Summary())
+		if err != nil {
+			fmt.Fprintf(stdout, "Error regenerating README: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *testsOnly != "" {
+		var spec *ProjectSpec
+		if *specFile != "" {
+			var err error
+			spec, err = loadSpec()
+			if err != nil {
+				fmt.Fprintf(stdout, "Error loading project specification: %v\n", err)
+				os.Exit(1)
 			}
 		}
+		err := agent.GenerateTestsOnly(*testsOnly, spec)
+		fmt.Fprintf(stdout, "📊 Usage: %s\n", agent.Usage.Summary())
+		if err != nil {
+			fmt.Fprintf(stdout, "Error generating tests: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+
+	if *watch {
+		if *specFile == "" {
+			fmt.Fprintln(stdout, "Error: -watch needs -spec-file")
+			os.Exit(1)
+		}
+		if err := agent.WatchSpec(*specFile, loadSpec); err != nil {
+			fmt.Fprintf(stdout, "Error watching project specification: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-		fmt.Println()
+	if *specFile != "" {
+		spec, err := loadSpec()
+		if err != nil {
+			fmt.Fprintf(stdout, "Error loading project specification: %v\n", err)
+			agent.Log.RecordError("Error loading project specification: %v", err)
+			os.Exit(1)
+		}
+		err = agent.GenerateCode(spec)
+		fmt.Fprintf(stdout, "📊 Usage: %s\n", agent.Usage.Summary())
+		if err != nil {
+			fmt.Fprintf(stdout, "Error generating project: %v\n", err)
+			agent.Log.RecordError("Error generating project: %v", err)
+			os.Exit(exitCode(err))
+		}
+		return
 	}
+
+	if *openAPIFile != "" {
+		doc, err := loadOpenAPI(*openAPIFile)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error loading OpenAPI spec: %v\n", err)
+			os.Exit(1)
+		}
+		prompt := openAPIPrompt(doc, strings.Join(flag.Args(), " "))
+		agent.Log.Debugf("OpenAPI prompt:\n%s", prompt)
+		if err := promptAndGenerate(agent, prompt, reader, *review, *maxFiles, *specFormat, *enhancePrompt, confirmation{Ask: isInteractive(), Yes: *yes, Default: confirmDefault}); err != nil {
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+
+	if *componentsOnly {
+		prompt := strings.TrimSpace(strings.Join(flag.Args(), " "))
+		if prompt == "" {
+			fmt.Fprintln(stdout, "Error: -components-only needs a project description as arguments")
+			os.Exit(1)
+		}
+		if *enhancePrompt {
+			prompt = enhanceInput(agent, prompt, reader, isInteractive())
+		}
+		spec, err := agent.GenerateOutline(prompt)
+		if err != nil {
+			fmt.Fprintf(stdout, "Error generating project outline: %v\n", err)
+			os.Exit(1)
+		}
+		printOutline(stdout, spec)
+		fmt.Fprintf(stdout, "\n📊 Usage: %s\n", agent.Usage.Summary())
+		return
+	}
+
+	// A description given as arguments is generated once; scripts without
+	// a terminal must confirm it with -yes
+	if prompt := strings.TrimSpace(strings.Join(flag.Args(), " ")); prompt != "" {
+		if err := promptAndGenerate(agent, prompt, reader, *review, *maxFiles, *specFormat, *enhancePrompt, confirmation{Ask: isInteractive(), Yes: *yes, Default: confirmDefault}); err != nil {
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+
+	fmt.Fprintln(stdout, "🧞 AI Project Generator (Type 'exit' to quit)")
+	fmt.Fprintln(stdout, "-------------------------------------------")
+	fmt.Fprintln(stdout, "I'm your project assistant! Describe what you want to build and I'll make it happen.")
+	fmt.Fprintln(stdout, "Example: 'Create a React dashboard with authentication, dark mode, and real-time charts'")
+	fmt.Fprintln(stdout, "Let's get started!")
+	fmt.Fprintln(stdout)
+
+	for {
+		fmt.Fprint(stdout, "Project description: ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintf(stdout, "Error reading input: %v\n", err)
+			continue
+		}
+
+		input = strings.TrimSpace(input)
+		if input == "exit" {
+			break
+		}
+
+		if input == "" {
+			continue
+		}
+
+		if promptAndGenerate(agent, input, reader, *review, *maxFiles, *specFormat, *enhancePrompt, confirmation{Ask: isInteractive(), Yes: *yes, Default: confirmDefault}) == nil {
+			fmt.Fprintln(stdout)
+		}
+	}
+}
+
+// confirmation decides whether generation proceeds once the spec is shown.
+type confirmation struct {
+	// Ask asks the user; without it generation only proceeds with Yes
+	Ask bool
+	// Yes proceeds without asking, as with -yes
+	Yes bool
+	// Default is the answer, y or n, when the user only presses Enter
+	Default string
+}
+
+// proceed reports whether to generate the project, asking on reader unless
+// Yes is set.
+func (c confirmation) proceed(reader *bufio.Reader) bool {
+	if c.Yes {
+		return true
+	}
+	choices := "y/N"
+	if c.Default == "y" {
+		choices = "Y/n"
+	}
+	fmt.Fprintf(stdout, "\nProceed with generation? (%s): ", choices)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.TrimSpace(strings.ToLower(answer))
+	if answer == "" {
+		answer = c.Default
+	}
+	return answer == "y" || answer == "yes"
+}
+
+// promptAndGenerate plans a project from a description, shows the spec in
+// specFormat and generates the project once confirm allows it. Errors are
+// printed before they are returned.
+func promptAndGenerate(agent *DevAgent, input string, reader *bufio.Reader, review bool, maxFiles int, specFormat string, enhance bool, confirm confirmation) error {
+	agent.Usage.Reset()
+
+	// Nobody could confirm, so stop before any API call
+	if !confirm.Ask && !confirm.Yes {
+		err := errors.New("not generating without confirmation: stdin is not a terminal, pass -yes to proceed")
+		fmt.Fprintf(stdout, "Error: %v\n", err)
+		return err
+	}
+
+	if enhance {
+		input = enhanceInput(agent, input, reader, !confirm.Yes)
+	}
+
+	// Generate project specification
+	spec, err := agent.GenerateProjectSpec(input)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error generating project specification: %v\n", err)
+		agent.Log.RecordError("Error generating project specification: %v", err)
+		return err
+	}
+
+	// Show specification and ask for confirmation
+	fmt.Fprintln(stdout, "\n📋 Project Specification:")
+	fmt.Fprintln(stdout, formatSpec(spec, specFormat))
+
+	if review {
+		spec = reviewSpec(agent, spec, reader, specFormat)
+	}
+
+	if !confirmLargeSpec(agent, spec, maxFiles, reader) {
+		return nil
+	}
+
+	if confirm.proceed(reader) {
+		err = agent.GenerateCode(spec)
+		fmt.Fprintf(stdout, "📊 Usage: %s\n", agent.Usage.Summary())
+		if err != nil {
+			fmt.Fprintf(stdout, "Error generating project: %v\n", err)
+			agent.Log.RecordError("Error generating project: %v", err)
+			return err
+		}
+	}
+
+	return nil
 }
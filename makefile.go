@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// makefileName is the task runner file written to the project root.
+const makefileName = "Makefile"
+
+// taskRunnerFiles are root files that already provide project commands.
+var taskRunnerFiles = []string{"Makefile", "makefile", "GNUmakefile", "justfile", "Justfile"}
+
+// GenerateMakefile asks the model for a Makefile with build, test, run and
+// lint targets that fit the generated project, and writes it to the project
+// root. It returns an empty path if the project already has a task runner.
+func (a *DevAgent) GenerateMakefile(spec *ProjectSpec, projectDir string, generatedFiles map[string]string) (string, error) {
+	for _, name := range taskRunnerFiles {
+		if _, ok := generatedFiles[name]; ok {
+			a.Log.Infof("⏭️  Skipping Makefile: the project already has %s", name)
+			return "", nil
+		}
+	}
+
+	if a.keepExisting(projectDir, makefileName) {
+		return "", nil
+	}
+
+	// The file list and dependency manifests describe how the project is
+	// built without sending every source file again
+	var filePaths []string
+	var manifests strings.Builder
+	for filePath, content := range generatedFiles {
+		filePaths = append(filePaths, filePath)
+		for _, manifestName := range dependencyManifests {
+			if filepath.Base(filePath) == manifestName {
+				manifests.WriteString(fmt.Sprintf("\n%s:\n```\n%s\n```\n", filePath, content))
+			}
+		}
+	}
+	sort.Strings(filePaths)
+
+	done := a.startFile(makefileName)
+
+	makefilePrompt := fmt.Sprintf(`Generate a Makefile for the root of the %s project.
+Project Description: %s
+Framework: %s
+
+Project files:
+%s
+%s
+Requirements:
+- Provide build, test, run and lint targets using the standard tools of the framework
+- Add an install target if dependencies must be installed before building
+- Declare every target as .PHONY and make help the default target listing them
+- Indent recipes with tabs
+Generate only the file content, no explanations.`, spec.Name, spec.Description, spec.Framework, strings.Join(filePaths, "\n"), manifests.String())
+
+	content, err := a.chat(chatRequest{
+		Label:       makefileName,
+		Model:       a.CodeModel,
+		System:      "You are an expert programmer. Generate only the Makefile, no explanations or markdown.",
+		Prompt:      a.wrapCodePrompt(makefilePrompt),
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate %s: %v", makefileName, err)
+	}
+
+	content = a.finalizeContent(makefileName, stripCodeFences(makefileName, content))
+	generatedFiles[makefileName] = content
+
+	err = writeProjectFile(projectDir, makefileName, content)
+	if err != nil {
+		return "", err
+	}
+	done()
+	return makefileName, nil
+}
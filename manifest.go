@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// manifestName is the file, relative to the project directory, that records
+// what the generator wrote.
+const manifestName = ".ashutosh-manifest.json"
+
+// Kinds of generated files recorded in the manifest
+const (
+	fileKindSource     = "source"
+	fileKindTest       = "test"
+	fileKindDependency = "dependency"
+	fileKindTooling    = "tooling"
+	fileKindMigration  = "migration"
+	fileKindReadme     = "readme"
+	fileKindDocs       = "docs"
+)
+
+// Manifest lists the files written for a generated project.
+type Manifest struct {
+	Name      string          `json:"name"`
+	Framework string          `json:"framework"`
+	Files     []ManifestEntry `json:"files"`
+}
+
+type ManifestEntry struct {
+	Path    string `json:"path"`
+	Kind    string `json:"kind"`
+	TestFor string `json:"test_for,omitempty"`
+	// DescriptionHash identifies the spec description a source file was
+	// generated from, for incremental regeneration
+	DescriptionHash string `json:"description_hash,omitempty"`
+	// Provider is the model provider that produced the file
+	Provider string `json:"provider,omitempty"`
+}
+
+// descriptionHash returns the hash stored for a file's spec description.
+func descriptionHash(description string) string {
+	sum := sha256.Sum256([]byte(description))
+	return hex.EncodeToString(sum[:])
+}
+
+func NewManifest(spec *ProjectSpec) *Manifest {
+	return &Manifest{
+		Name:      spec.Name,
+		Framework: spec.Framework,
+	}
+}
+
+// Add records a written file, replacing any earlier entry for the same path.
+func (m *Manifest) Add(path, kind string) *ManifestEntry {
+	for i := range m.Files {
+		if m.Files[i].Path == path {
+			m.Files[i] = ManifestEntry{Path: path, Kind: kind}
+			return &m.Files[i]
+		}
+	}
+	m.Files = append(m.Files, ManifestEntry{Path: path, Kind: kind})
+	return &m.Files[len(m.Files)-1]
+}
+
+// Entry returns the entry for the given path, or nil.
+func (m *Manifest) Entry(path string) *ManifestEntry {
+	for i := range m.Files {
+		if m.Files[i].Path == path {
+			return &m.Files[i]
+		}
+	}
+	return nil
+}
+
+// TestsFor returns the entries of the tests generated for a source file.
+func (m *Manifest) TestsFor(source string) []ManifestEntry {
+	var tests []ManifestEntry
+	for _, entry := range m.Files {
+		if entry.Kind == fileKindTest && entry.TestFor == source {
+			tests = append(tests, entry)
+		}
+	}
+	return tests
+}
+
+// ReadManifest loads the manifest of a previously generated project. It
+// returns nil without an error if the project has no manifest.
+func ReadManifest(projectDir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(projectDir, manifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, describeFSError("read", manifestName, err)
+	}
+
+	var m Manifest
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", manifestName, err)
+	}
+	return &m, nil
+}
+
+// Write saves the manifest into the project directory.
+func (m *Manifest) Write(projectDir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %v", err)
+	}
+	return writeProjectFile(projectDir, manifestName, string(data)+"\n")
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Markers of a hand-edited region that survives regeneration, written as
+// comments in the file's syntax, e.g. "// BEGIN MANUAL auth" and
+// "// END MANUAL". The text after BEGIN MANUAL names the region.
+const (
+	manualBegin = "BEGIN MANUAL"
+	manualEnd   = "END MANUAL"
+)
+
+// manualRegion is a hand-edited region of a file.
+type manualRegion struct {
+	// Name is the text after BEGIN MANUAL, possibly empty
+	Name string
+	// Lines are the region's lines, including both markers
+	Lines []string
+	// Anchor is the last non-empty line before the region, or empty if it
+	// starts the file
+	Anchor string
+}
+
+// manualMarker reports whether line is a comment holding marker and
+// returns the text after it, without a closing comment delimiter.
+func manualMarker(line, marker string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	i := strings.Index(trimmed, marker)
+	if i < 0 || strings.Trim(trimmed[:i], "/#-*<!;% ") != "" {
+		return "", false
+	}
+	rest := strings.TrimSpace(trimmed[i+len(marker):])
+	rest = strings.TrimSuffix(strings.TrimSuffix(rest, "*/"), "-->")
+	return strings.TrimSpace(rest), true
+}
+
+// manualRegions returns the hand-edited regions of content, in order. A
+// region that is not closed, or opened inside another, is an error.
+func manualRegions(content string) ([]manualRegion, error) {
+	var regions []manualRegion
+	var current *manualRegion
+	anchor := ""
+	for i, line := range strings.Split(content, "\n") {
+		if current != nil {
+			current.Lines = append(current.Lines, line)
+			if _, ok := manualMarker(line, manualBegin); ok {
+				return nil, fmt.Errorf("line %d: %s inside the region opened before it", i+1, manualBegin)
+			}
+			if _, ok := manualMarker(line, manualEnd); ok {
+				regions = append(regions, *current)
+				current = nil
+				anchor = strings.TrimSpace(line)
+			}
+			continue
+		}
+		if name, ok := manualMarker(line, manualBegin); ok {
+			current = &manualRegion{Name: name, Lines: []string{line}, Anchor: anchor}
+			continue
+		}
+		if _, ok := manualMarker(line, manualEnd); ok {
+			return nil, fmt.Errorf("line %d: %s without %s", i+1, manualEnd, manualBegin)
+		}
+		if strings.TrimSpace(line) != "" {
+			anchor = strings.TrimSpace(line)
+		}
+	}
+	if current != nil {
+		return nil, fmt.Errorf("%s %s is not closed with %s", manualBegin, current.Name, manualEnd)
+	}
+	return regions, nil
+}
+
+// spliceManualRegions puts regions back into regenerated content. A region
+// whose markers the new content keeps replaces what is between them, the
+// nth region of a name taking the nth markers of that name;
+// otherwise it is inserted after its anchor line, or appended at the end
+// if the anchor is gone, in which case its name is returned as misplaced.
+func spliceManualRegions(content string, regions []manualRegion) (string, []string) {
+	lines := strings.Split(content, "\n")
+	var misplaced []string
+	// occurrence counts the regions of each name so far, so the second
+	// region of a name goes between the second pair of its markers
+	occurrence := make(map[string]int)
+	for _, region := range regions {
+		n := occurrence[region.Name]
+		occurrence[region.Name]++
+		begin, end := -1, -1
+		for i, line := range lines {
+			if name, ok := manualMarker(line, manualBegin); ok && begin < 0 && name == region.Name {
+				if n > 0 {
+					n--
+					continue
+				}
+				begin = i
+				continue
+			}
+			if _, ok := manualMarker(line, manualEnd); ok && begin >= 0 {
+				end = i
+				break
+			}
+		}
+		if begin >= 0 && end >= 0 {
+			lines = append(lines[:begin], append(append([]string(nil), region.Lines...), lines[end+1:]...)...)
+			continue
+		}
+
+		at := -1
+		if region.Anchor == "" {
+			at = 0
+		}
+		for i, line := range lines {
+			if region.Anchor != "" && strings.TrimSpace(line) == region.Anchor {
+				at = i + 1
+				break
+			}
+		}
+		if at < 0 {
+			// Keep the final newline last
+			at = len(lines)
+			if at > 0 && lines[at-1] == "" {
+				at--
+			}
+			misplaced = append(misplaced, region.Name)
+		}
+		lines = append(lines[:at], append(append([]string(nil), region.Lines...), lines[at:]...)...)
+	}
+	return strings.Join(lines, "\n"), misplaced
+}
+
+// keepManualEdits splices the hand-edited regions of the file on disk into
+// its regenerated content. Files without markers, or not yet on disk, are
+// returned unchanged, as is content when the markers on disk are broken.
+func (r *generationRun) keepManualEdits(filePath, content string) string {
+	if r.inMemory {
+		return content
+	}
+	existing, err := os.ReadFile(filepath.Join(r.projectDir, filePath))
+	if err != nil || !strings.Contains(string(existing), manualBegin) {
+		return content
+	}
+	regions, err := manualRegions(string(existing))
+	if err != nil {
+		r.agent.Log.Warnf("Not keeping the manual edits of %s: %v", filePath, err)
+		return content
+	}
+	if len(regions) == 0 {
+		return content
+	}
+
+	content, misplaced := spliceManualRegions(content, regions)
+	r.agent.Log.Infof("✋ Kept %d manual region(s) of %s", len(regions), filePath)
+	if len(misplaced) > 0 {
+		r.agent.Log.Warnf("Appended manual region(s) of %s whose place is gone: %s", filePath, strings.Join(quoteNames(misplaced), ", "))
+	}
+	return content
+}
+
+// quoteNames quotes region names, so that unnamed regions show as "".
+func quoteNames(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return quoted
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSpliceManualRegions(t *testing.T) {
+	tests := []struct {
+		name      string
+		old       string
+		new       string
+		want      string
+		misplaced []string
+	}{
+		{
+			"named region",
+			"a\n// BEGIN MANUAL auth\nmine\n// END MANUAL\nb\n",
+			"a\n// BEGIN MANUAL auth\nmodel\n// END MANUAL\nb\n",
+			"a\n// BEGIN MANUAL auth\nmine\n// END MANUAL\nb\n",
+			nil,
+		},
+		{
+			"two unnamed regions",
+			"a\n// BEGIN MANUAL\nmine1\n// END MANUAL\nb\n// BEGIN MANUAL\nmine2\n// END MANUAL\nc\n",
+			"a\n// BEGIN MANUAL\nmodel1\n// END MANUAL\nb\n// BEGIN MANUAL\nmodel2\n// END MANUAL\nc\n",
+			"a\n// BEGIN MANUAL\nmine1\n// END MANUAL\nb\n// BEGIN MANUAL\nmine2\n// END MANUAL\nc\n",
+			nil,
+		},
+		{
+			"markers gone",
+			"a\n// BEGIN MANUAL\nmine\n// END MANUAL\nb\n",
+			"a\nb\n",
+			"a\n// BEGIN MANUAL\nmine\n// END MANUAL\nb\n",
+			nil,
+		},
+		{
+			"anchor gone",
+			"a\n// BEGIN MANUAL x\nmine\n// END MANUAL\n",
+			"b\n",
+			"b\n// BEGIN MANUAL x\nmine\n// END MANUAL\n",
+			[]string{"x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regions, err := manualRegions(tt.old)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, misplaced := spliceManualRegions(tt.new, regions)
+			if got != tt.want {
+				t.Errorf("spliceManualRegions() = %q, want %q", got, tt.want)
+			}
+			if strings.Join(misplaced, ",") != strings.Join(tt.misplaced, ",") {
+				t.Errorf("misplaced = %q, want %q", misplaced, tt.misplaced)
+			}
+		})
+	}
+}
+
+func TestManualRegionsErrors(t *testing.T) {
+	for _, content := range []string{
+		"// BEGIN MANUAL\nx\n",
+		"// END MANUAL\n",
+		"// BEGIN MANUAL\n// BEGIN MANUAL\n// END MANUAL\n",
+	} {
+		if _, err := manualRegions(content); err == nil {
+			t.Errorf("manualRegions(%q) did not fail", content)
+		}
+	}
+}
+
+func TestFinishLimitsManualRegions(t *testing.T) {
+	projectDir := t.TempDir()
+	manual := "// BEGIN MANUAL\n" + strings.Repeat("// mine\n", 20) + "// END MANUAL\n"
+	writeTestFile(t, projectDir, "main.go", "package main\n\n"+manual)
+
+	spec := validSpec()
+	a := &DevAgent{MaxFileSize: 100, Log: NewLogger()}
+	r := &generationRun{agent: a, spec: spec, projectDir: projectDir, files: make(map[string]string), manifest: NewManifest(spec)}
+	if err := r.finish("main.go", "package main\n\n// BEGIN MANUAL\n// END MANUAL\n", nil, func() {}); err != nil {
+		t.Fatal(err)
+	}
+	content := r.files["main.go"]
+	if !strings.Contains(content, "[truncated") || len(content) > 200 {
+		t.Errorf("main.go was not truncated after splicing the manual region:\n%s", content)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// migrationTool describes the file layout and dialect of a migration tool.
+type migrationTool struct {
+	// Dir is where the tool looks for migrations, relative to the project
+	Dir string
+	// Dialect tells the model what to write in the up and down sections
+	Dialect string
+	// files renders one migration as its files, keyed by path below Dir
+	files func(m migration, version, previous string) map[string]string
+}
+
+// migration is one step of a generated migration set.
+type migration struct {
+	Name string `json:"name"`
+	Up   string `json:"up"`
+	Down string `json:"down"`
+}
+
+// migrationTools are the tools accepted by -migrations.
+var migrationTools = map[string]migrationTool{
+	"goose": {
+		Dir:     "migrations",
+		Dialect: "SQL statements",
+		files: func(m migration, version, _ string) map[string]string {
+			return map[string]string{
+				version + "_" + m.Name + ".sql": fmt.Sprintf("-- +goose Up\n%s\n\n-- +goose Down\n%s\n", m.Up, m.Down),
+			}
+		},
+	},
+	"migrate": {
+		Dir:     "migrations",
+		Dialect: "SQL statements",
+		files: func(m migration, version, _ string) map[string]string {
+			return map[string]string{
+				version + "_" + m.Name + ".up.sql":   m.Up + "\n",
+				version + "_" + m.Name + ".down.sql": m.Down + "\n",
+			}
+		},
+	},
+	"alembic": {
+		Dir:     "alembic/versions",
+		Dialect: "Python statements using alembic's op and sqlalchemy as sa, indented by four spaces",
+		files: func(m migration, version, previous string) map[string]string {
+			downRevision := "None"
+			if previous != "" {
+				downRevision = fmt.Sprintf("%q", previous)
+			}
+			return map[string]string{
+				version + "_" + m.Name + ".py": fmt.Sprintf(`"""%s"""
+from alembic import op
+import sqlalchemy as sa
+
+revision = %q
+down_revision = %s
+branch_labels = None
+depends_on = None
+
+
+def upgrade():
+%s
+
+
+def downgrade():
+%s
+`, strings.ReplaceAll(m.Name, "_", " "), version, downRevision, pythonBody(m.Up), pythonBody(m.Down)),
+			}
+		},
+	},
+}
+
+// migrationToolNames returns the supported migration tools in alphabetical
+// order.
+func migrationToolNames() []string {
+	names := make([]string, 0, len(migrationTools))
+	for name := range migrationTools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pythonBody indents statements as a function body, using pass if there
+// are none.
+func pythonBody(statements string) string {
+	statements = strings.TrimRight(statements, "\n ")
+	if strings.TrimSpace(statements) == "" {
+		return "    pass"
+	}
+	lines := strings.Split(statements, "\n")
+	for i, line := range lines {
+		if line != "" && !strings.HasPrefix(line, "    ") {
+			lines[i] = "    " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// databaseKeywords mark a spec as using a database.
+var databaseKeywords = []string{"database", "db", "sql", "postgres", "postgresql", "mysql", "mariadb", "sqlite", "orm", "gorm", "sqlalchemy", "prisma", "sequelize", "typeorm", "migration", "migrations"}
+
+// usesDatabase reports whether the spec's components, framework,
+// description or files mention a relational database.
+func usesDatabase(spec *ProjectSpec) bool {
+	texts := append([]string{spec.Framework, spec.Description}, spec.Components...)
+	for filePath, description := range spec.Files {
+		texts = append(texts, filePath, description)
+	}
+	for _, text := range texts {
+		text = strings.ToLower(text)
+		for _, keyword := range databaseKeywords {
+			if containsWord(text, keyword) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schemaFileHints are path fragments of files that define the data model.
+var schemaFileHints = []string{"model", "schema", "entit", "db", "database", "repositor", "store"}
+
+// migrationNamePattern matches the characters allowed in migration names.
+var migrationNamePattern = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// GenerateMigrations asks the model for the ordered migrations that create
+// the project's database schema and writes them in the layout of the given
+// tool, with versions one second apart so they sort in order. It returns
+// the written paths, or none if the project uses no database or already
+// has migrations.
+func (a *DevAgent) GenerateMigrations(spec *ProjectSpec, projectDir, toolName string, generatedFiles map[string]string) ([]string, error) {
+	tool, ok := migrationTools[toolName]
+	if !ok {
+		return nil, fmt.Errorf("unknown migration tool %q (valid tools: %s)", toolName, strings.Join(migrationToolNames(), ", "))
+	}
+	if !usesDatabase(spec) {
+		a.Log.Infof("⏭️  Skipping migrations: the project does not use a database")
+		return nil, nil
+	}
+	for filePath := range generatedFiles {
+		if strings.HasPrefix(filePath, tool.Dir+"/") {
+			a.Log.Infof("⏭️  Skipping migrations: the project already has %s", filePath)
+			return nil, nil
+		}
+	}
+	// Versions are timestamps, so migrations from an earlier run would be
+	// duplicated rather than replaced
+	if entries, err := os.ReadDir(filepath.Join(projectDir, tool.Dir)); err == nil && len(entries) > 0 {
+		a.Log.Infof("⏭️  Skipping migrations: %s already exists", tool.Dir)
+		return nil, nil
+	}
+
+	var schemaFiles strings.Builder
+	var paths []string
+	for filePath := range generatedFiles {
+		for _, hint := range schemaFileHints {
+			if strings.Contains(strings.ToLower(filePath), hint) {
+				paths = append(paths, filePath)
+				break
+			}
+		}
+	}
+	sort.Strings(paths)
+	for _, filePath := range paths {
+		schemaFiles.WriteString(fmt.Sprintf("\n%s:\n```\n%s\n```\n", filePath, generatedFiles[filePath]))
+	}
+
+	done := a.startFile(tool.Dir)
+
+	migrationPrompt := fmt.Sprintf(`Write the database migrations for the %s project, to be run with %s.
+Project Description: %s
+Framework: %s
+Components: %s
+
+Files that define the data model:
+%s
+Requirements:
+- Create every table, column, index and constraint the data model needs
+- Order the migrations so that each only depends on the ones before it
+- Write the up and down sections as %s; each down section must exactly undo its up section
+- Name each migration in snake_case, e.g. create_users
+Respond only with valid JSON in the following structure:
+{
+  "migrations": [
+    {"name": "<migration name>", "up": "<up section>", "down": "<down section>"}
+  ]
+}`, spec.Name, toolName, spec.Description, spec.Framework, strings.Join(spec.Components, ", "), schemaFiles.String(), tool.Dialect)
+
+	content, err := a.chat(chatRequest{
+		Label:       tool.Dir,
+		Model:       a.CodeModel,
+		System:      "You are an expert database engineer. Respond only with the requested JSON, no explanations or markdown.",
+		Prompt:      a.wrapCodePrompt(migrationPrompt),
+		Temperature: 0.2,
+		JSON:        true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate migrations: %v", err)
+	}
+
+	var reply struct {
+		Migrations []migration `json:"migrations"`
+	}
+	if err := a.parseModelJSON(content, &reply); err != nil {
+		return nil, fmt.Errorf("failed to parse migrations: %v", err)
+	}
+
+	start := time.Now().UTC()
+	previous := ""
+	var written []string
+	for i, m := range reply.Migrations {
+		m.Name = strings.Trim(migrationNamePattern.ReplaceAllString(strings.ToLower(m.Name), "_"), "_")
+		if m.Name == "" {
+			m.Name = fmt.Sprintf("migration_%d", i+1)
+		}
+		version := start.Add(time.Duration(i) * time.Second).Format("20060102150405")
+
+		files := tool.files(m, version, previous)
+		names := make([]string, 0, len(files))
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			filePath := tool.Dir + "/" + name
+			fileContent := a.finalizeContent(filePath, files[name])
+			generatedFiles[filePath] = fileContent
+			if err := writeProjectFile(projectDir, filePath, fileContent); err != nil {
+				return written, err
+			}
+			written = append(written, filePath)
+		}
+		previous = version
+	}
+	done()
+	return written, nil
+}
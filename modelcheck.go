@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// modelLister is implemented by providers that can list their models.
+type modelLister interface {
+	ListModels(ctx context.Context) (openai.ModelsList, error)
+}
+
+func (p *compatibleProvider) ListModels(ctx context.Context) (openai.ModelsList, error) {
+	return p.client.ListModels(ctx)
+}
+
+// maxModelSuggestions is how many alternatives are offered for a model
+// that is not available.
+const maxModelSuggestions = 3
+
+// unavailableModel is a configured model the primary provider does not
+// serve, with the closest models it does serve.
+type unavailableModel struct {
+	Phase       string
+	Model       string
+	Suggestions []string
+}
+
+// CheckModels asks the primary provider which models it serves and returns
+// the configured models that are missing. Providers that cannot list their
+// models, or that replace the requested model, are not checked.
+func (a *DevAgent) CheckModels() []unavailableModel {
+	if len(a.Providers) == 0 {
+		return nil
+	}
+	primary, ok := a.Providers[0].(*compatibleProvider)
+	if !ok || primary.model != "" {
+		return nil
+	}
+	list, err := primary.ListModels(a.ctx)
+	if err != nil {
+		a.Log.Debugf("Skipping model check: %v", err)
+		return nil
+	}
+
+	available := make(map[string]bool, len(list.Models))
+	var ids []string
+	for _, model := range list.Models {
+		id := strings.TrimPrefix(model.ID, primary.modelPrefix)
+		available[id] = true
+		ids = append(ids, id)
+	}
+
+	phases := map[string]string{"spec": a.SpecModel, "code": a.CodeModel, "readme": a.ReadmeModel}
+	for language, model := range a.LanguageModels {
+		phases["code."+language] = model
+	}
+	var names []string
+	for phase := range phases {
+		names = append(names, phase)
+	}
+	sort.Strings(names)
+
+	var missing []unavailableModel
+	for _, phase := range names {
+		model := phases[phase]
+		if available[model] {
+			continue
+		}
+		missing = append(missing, unavailableModel{
+			Phase:       phase,
+			Model:       model,
+			Suggestions: closestModels(model, ids, maxModelSuggestions),
+		})
+	}
+	return missing
+}
+
+// closestModels returns up to n of the ids most similar to model by edit
+// distance.
+func closestModels(model string, ids []string, n int) []string {
+	ranked := append([]string(nil), ids...)
+	distance := make(map[string]int, len(ranked))
+	for _, id := range ranked {
+		distance[id] = editDistance(model, id)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if distance[ranked[i]] != distance[ranked[j]] {
+			return distance[ranked[i]] < distance[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
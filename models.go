@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// modelPhases lists the generation phases that can use their own model.
+var modelPhases = []string{"spec", "code", "readme", "enhance", "summary"}
+
+// parseModelMap parses a comma-separated list of phase=model pairs such as
+// "spec=gpt-4o-mini,code=gpt-4-turbo,readme=gpt-4o". The code phase can be
+// narrowed to one language, as in "code.go=gpt-4o".
+func parseModelMap(value string) (map[string]string, error) {
+	models := make(map[string]string)
+	var unknown []string
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		phase, model, ok := strings.Cut(pair, "=")
+		phase = strings.TrimSpace(phase)
+		model = strings.TrimSpace(model)
+		if !ok || phase == "" || model == "" {
+			return nil, fmt.Errorf("invalid entry %q, expected phase=model", pair)
+		}
+		if !isModelPhase(phase) {
+			unknown = append(unknown, phase)
+			continue
+		}
+		models[phase] = model
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown phase(s) %s (valid phases: %s, or code.<language> for one of %s)", strings.Join(unknown, ", "), strings.Join(modelPhases, ", "), strings.Join(languageNames(), ", "))
+	}
+	return models, nil
+}
+
+func isModelPhase(phase string) bool {
+	if language, ok := strings.CutPrefix(phase, "code."); ok {
+		return isSourceLanguage(language)
+	}
+	for _, p := range modelPhases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// SetModels overrides the model of each phase present in models.
+func (a *DevAgent) SetModels(models map[string]string) {
+	for phase, model := range models {
+		switch phase {
+		case "spec":
+			a.SpecModel = model
+		case "code":
+			a.CodeModel = model
+		case "readme":
+			a.ReadmeModel = model
+		case "enhance":
+			a.EnhanceModel = model
+		case "summary":
+			a.SummaryModel = model
+		default:
+			if language, ok := strings.CutPrefix(phase, "code."); ok {
+				a.LanguageModels[language] = model
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import "strings"
+
+// ModelStyle adjusts the prompts sent to a family of models.
+type ModelStyle struct {
+	// System is added to the system prompt of every request to the model
+	System string `json:"system"`
+}
+
+// defaultModelStyles maps model name prefixes to the prompt style they
+// respond best to; the config file can add more or replace them.
+var defaultModelStyles = map[string]ModelStyle{
+	"o1":            {System: "Be terse and direct. Do not restate the task or explain your reasoning."},
+	"o3":            {System: "Be terse and direct. Do not restate the task or explain your reasoning."},
+	"o4-mini":       {System: "Be terse and direct. Do not restate the task or explain your reasoning."},
+	"gpt-4":         {System: "Work through the requirements carefully and completely. Cover edge cases and keep the output consistent with everything described."},
+	"gpt-3.5-turbo": {System: "Follow every requirement exactly and keep to the requested output format."},
+}
+
+// modelStyle returns the prompt style of model: the entry of ModelStyles
+// with the longest prefix of the model name.
+func (a *DevAgent) modelStyle(model string) (ModelStyle, bool) {
+	best := ""
+	for prefix := range a.ModelStyles {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return ModelStyle{}, false
+	}
+	return a.ModelStyles[best], true
+}
+
+// styledSystemPrompt adds the model's prompt style to a system prompt.
+func (a *DevAgent) styledSystemPrompt(model, system string) string {
+	style, ok := a.modelStyle(model)
+	if !ok || style.System == "" {
+		return system
+	}
+	return system + "\n\n" + style.System
+}
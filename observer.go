@@ -0,0 +1,55 @@
+package main
+
+import "time"
+
+// Observer is notified about the progress of GenerateCode, so embedders
+// can report progress without parsing console output.
+type Observer interface {
+	// OnFileStart is called before a file is generated.
+	OnFileStart(path string)
+	// OnFileDone is called after a file was generated and written, with
+	// the tokens its API calls consumed and how long it took.
+	OnFileDone(path string, tokens int, duration time.Duration)
+	// OnError is called when a file fails and generation continues
+	// without it. Errors that abort generation are returned instead.
+	OnError(path string, err error)
+}
+
+// logObserver prints generation events to the console.
+type logObserver struct {
+	log *Logger
+}
+
+func (o *logObserver) OnFileStart(path string) {
+	o.log.Infof("⚙️  Generating %s...", path)
+}
+
+func (o *logObserver) OnFileDone(path string, tokens int, duration time.Duration) {
+	o.log.Debugf("Generated %s (%d tokens in %s)", path, tokens, duration.Round(time.Millisecond))
+}
+
+func (o *logObserver) OnError(path string, err error) {
+	o.log.Warnf("%v, continuing", err)
+}
+
+// startFile notifies the observer that a file is being generated and
+// returns a function that reports it done.
+func (a *DevAgent) startFile(path string) func() {
+	if a.Observer == nil {
+		return func() {}
+	}
+	a.Observer.OnFileStart(path)
+	start := time.Now()
+	before := a.Usage.TokensFor(path)
+	return func() {
+		a.Observer.OnFileDone(path, a.Usage.TokensFor(path)-before, time.Since(start))
+	}
+}
+
+// fileFailed notifies the observer of a file that was given up on.
+func (a *DevAgent) fileFailed(path string, err error) {
+	a.Log.RecordError("%s: %v", path, err)
+	if a.Observer != nil {
+		a.Observer.OnError(path, err)
+	}
+}
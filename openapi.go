@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPIMethods lists the operations of a path item in the order they are
+// summarized.
+var openAPIMethods = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+// loadOpenAPI reads an OpenAPI or Swagger document. YAML is a superset of
+// JSON, so one decoder handles both formats.
+func loadOpenAPI(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI file: %v", err)
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI file %s: %v", path, err)
+	}
+	doc = stringKeys(doc).(map[string]interface{})
+	if doc["openapi"] == nil && doc["swagger"] == nil {
+		return nil, fmt.Errorf("%s is not an OpenAPI or Swagger document", path)
+	}
+	if len(mapField(doc, "paths")) == 0 {
+		return nil, fmt.Errorf("%s does not define any paths", path)
+	}
+	return doc, nil
+}
+
+// stringKeys converts the maps YAML decodes with non-string keys, such as
+// unquoted status codes like 200, to maps keyed by strings, so that they
+// are looked up like the maps decoded from JSON.
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = stringKeys(value)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = stringKeys(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = stringKeys(value)
+		}
+		return v
+	}
+	return v
+}
+
+// mapField returns the object stored under key, or nil if there is none.
+func mapField(m map[string]interface{}, key string) map[string]interface{} {
+	v, _ := m[key].(map[string]interface{})
+	return v
+}
+
+// stringField returns the string stored under key, or an empty string.
+func stringField(m map[string]interface{}, key string) string {
+	v, _ := m[key].(string)
+	return strings.TrimSpace(v)
+}
+
+// sortedKeys returns the keys of m in alphabetical order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// schemaName describes a schema by its $ref name or its type.
+func schemaName(schema map[string]interface{}) string {
+	if ref := stringField(schema, "$ref"); ref != "" {
+		return ref[strings.LastIndex(ref, "/")+1:]
+	}
+	if items := mapField(schema, "items"); items != nil {
+		return "array of " + schemaName(items)
+	}
+	return stringField(schema, "type")
+}
+
+// summarizeOpenAPI renders the endpoints and models of an OpenAPI document as
+// a compact plain-text list that fits into a prompt.
+func summarizeOpenAPI(doc map[string]interface{}) string {
+	var b strings.Builder
+
+	info := mapField(doc, "info")
+	if title := stringField(info, "title"); title != "" {
+		fmt.Fprintf(&b, "API: %s", title)
+		if version := stringField(info, "version"); version != "" {
+			fmt.Fprintf(&b, " (version %s)", version)
+		}
+		b.WriteString("\n")
+	}
+	if description := stringField(info, "description"); description != "" {
+		fmt.Fprintf(&b, "Description: %s\n", description)
+	}
+	if basePath := stringField(doc, "basePath"); basePath != "" {
+		fmt.Fprintf(&b, "Base path: %s\n", basePath)
+	}
+
+	b.WriteString("\nEndpoints:\n")
+	paths := mapField(doc, "paths")
+	for _, path := range sortedKeys(paths) {
+		item, _ := paths[path].(map[string]interface{})
+		for _, method := range openAPIMethods {
+			op := mapField(item, method)
+			if op == nil {
+				continue
+			}
+			fmt.Fprintf(&b, "- %s %s", strings.ToUpper(method), path)
+			if summary := stringField(op, "summary"); summary != "" {
+				fmt.Fprintf(&b, ": %s", summary)
+			} else if id := stringField(op, "operationId"); id != "" {
+				fmt.Fprintf(&b, ": %s", id)
+			}
+			b.WriteString("\n")
+
+			// Parameters on the path item apply to every operation below it
+			shared, _ := item["parameters"].([]interface{})
+			own, _ := op["parameters"].([]interface{})
+			params := append(append([]interface{}{}, shared...), own...)
+			for _, p := range params {
+				param, _ := p.(map[string]interface{})
+				name := stringField(param, "name")
+				if name == "" {
+					continue
+				}
+				fmt.Fprintf(&b, "    param %s (%s)", name, stringField(param, "in"))
+				if required, _ := param["required"].(bool); required {
+					b.WriteString(" required")
+				}
+				b.WriteString("\n")
+			}
+
+			content := mapField(mapField(op, "requestBody"), "content")
+			for _, mediaType := range sortedKeys(content) {
+				body, _ := content[mediaType].(map[string]interface{})
+				fmt.Fprintf(&b, "    body %s %s\n", mediaType, schemaName(mapField(body, "schema")))
+			}
+
+			responses := mapField(op, "responses")
+			if len(responses) > 0 {
+				fmt.Fprintf(&b, "    responses %s\n", strings.Join(sortedKeys(responses), ", "))
+			}
+		}
+	}
+
+	// OpenAPI 3 keeps models under components, Swagger 2 under definitions
+	schemas := mapField(mapField(doc, "components"), "schemas")
+	if schemas == nil {
+		schemas = mapField(doc, "definitions")
+	}
+	if len(schemas) > 0 {
+		b.WriteString("\nModels:\n")
+		for _, name := range sortedKeys(schemas) {
+			schema, _ := schemas[name].(map[string]interface{})
+			properties := mapField(schema, "properties")
+			var fields []string
+			for _, field := range sortedKeys(properties) {
+				property, _ := properties[field].(map[string]interface{})
+				fields = append(fields, fmt.Sprintf("%s %s", field, schemaName(property)))
+			}
+			fmt.Fprintf(&b, "- %s: %s\n", name, strings.Join(fields, ", "))
+		}
+	}
+
+	return b.String()
+}
+
+// openAPIPrompt builds a project description asking for a server that
+// implements the given OpenAPI document. notes carries optional extra
+// instructions such as the language or framework to use.
+func openAPIPrompt(doc map[string]interface{}, notes string) string {
+	prompt := fmt.Sprintf(`Create a backend service that implements the following API contract exactly.
+Every endpoint needs a handler with the listed parameters, request bodies and response codes,
+and every model needs a matching type with the listed fields.
+
+%s`, summarizeOpenAPI(doc))
+	if notes = strings.TrimSpace(notes); notes != "" {
+		prompt += "\nAdditional requirements: " + notes + "\n"
+	}
+	return prompt
+}
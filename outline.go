@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// outlineSpecTemplate is the spec template without file planning, for
+// brainstorming a project's architecture.
+const outlineSpecTemplate = `As an AI development agent, analyze the user's request and outline the architecture of the project.
+Think through this step by step:
+
+1. Understand the core requirements
+2. Identify the best framework and technologies
+3. Break down the components needed
+
+Do not plan individual files.
+{{.ProfileInstruction}}
+Respond only with valid JSON in the following structure:
+{
+  "name": "<project name>",
+  "type": "<project type>",
+  "framework": "<recommended framework>",
+  "components": [
+    "<component 1 and its responsibility>",
+    "<component 2 and its responsibility>",
+    ...
+  ],
+  "description": "<project description>"
+}`
+
+var outlineSpecTmpl = template.Must(parsePromptTemplate("outline", outlineSpecTemplate, sampleSpecData))
+
+// GenerateOutline asks the model for the components and description of a
+// project without planning its files. The returned spec has no files.
+func (a *DevAgent) GenerateOutline(prompt string) (*ProjectSpec, error) {
+	systemPrompt, err := renderPrompt(outlineSpecTmpl, specPromptData{Prompt: prompt, ProfileInstruction: a.specProfileInstruction()})
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := a.chat(chatRequest{
+		Label:       "project outline",
+		Model:       a.SpecModel,
+		System:      systemPrompt,
+		Prompt:      prompt,
+		Temperature: 0.2,
+		JSON:        true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate project outline: %v", err)
+	}
+
+	var spec ProjectSpec
+	if err := a.parseModelJSON(content, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse project outline: %v", err)
+	}
+	if len(spec.Components) == 0 {
+		return nil, fmt.Errorf("invalid project outline: no components are listed")
+	}
+	return &spec, nil
+}
+
+// printOutline prints the description and components of an outline.
+func printOutline(w io.Writer, spec *ProjectSpec) {
+	fmt.Fprintf(w, "\n🧩 %s (%s, %s)\n", spec.Name, spec.Type, spec.Framework)
+	if description := strings.TrimSpace(spec.Description); description != "" {
+		fmt.Fprintf(w, "\n%s\n", description)
+	}
+	fmt.Fprintln(w, "\nComponents:")
+	for _, component := range spec.Components {
+		fmt.Fprintf(w, "  - %s\n", component)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// safeProjectName turns a project name into one that is safe as a single
+// directory name, replacing other characters than letters, digits, dashes
+// and underscores with dashes.
+func safeProjectName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, name)
+	if name == "" {
+		return "project"
+	}
+	return name
+}
+
+// named returns spec renamed to Name, if that is set, keeping the name the
+// model suggested in the log.
+func (a *DevAgent) named(spec *ProjectSpec) *ProjectSpec {
+	if a.Name == "" || a.Name == spec.Name {
+		return spec
+	}
+	a.Log.Infof("📛 Naming the project %s (suggested name: %s)", a.Name, spec.Name)
+	renamed := *spec
+	renamed.Name = a.Name
+	return &renamed
+}
+
+// ProjectDir returns the directory below OutputDir the project is
+// generated into; isolated runs use a temporary directory instead.
+func (a *DevAgent) ProjectDir(spec *ProjectSpec) string {
+	return filepath.Join(a.OutputDir, spec.Name)
+}
+
+// ImportPath returns the module/import path of the project, derived from
+// the import base and the project's location below the monorepo root.
+// It returns an empty string when no import base is configured.
+func (a *DevAgent) ImportPath(spec *ProjectSpec) string {
+	if a.ImportBase == "" {
+		return ""
+	}
+	if a.OutputDir == "" || filepath.IsAbs(a.OutputDir) {
+		return path.Join(a.ImportBase, spec.Name)
+	}
+	return path.Join(a.ImportBase, filepath.ToSlash(filepath.Clean(a.OutputDir)), spec.Name)
+}
+
+// importInstruction returns a prompt requirement line telling the model
+// which module path to use, or an empty string without an import base.
+// In a Go workspace it names the path of every module.
+func (a *DevAgent) importInstruction(spec *ProjectSpec) string {
+	if instruction := a.workspaceInstruction(spec); instruction != "" {
+		return instruction
+	}
+	importPath := a.ImportPath(spec)
+	if importPath == "" {
+		return ""
+	}
+	return fmt.Sprintf("- Use %s as the module path and import other project packages relative to it\n", importPath)
+}
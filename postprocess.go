@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// finalizeContent applies the post-processing steps to generated content
+// before it is written to filePath. Every file ends in a single newline;
+// the other steps are optional.
+func (a *DevAgent) finalizeContent(filePath, content string) string {
+	if a.Format {
+		formatted, err := a.formatContent(filePath, content)
+		if err != nil {
+			a.Log.Warnf("Could not format %s: %v", filePath, err)
+		}
+		content = formatted
+	}
+	content = ensureTrailingNewline(content)
+	if a.Header != "" {
+		content = prependHeader(filePath, a.Header, content)
+	}
+
+	if a.RedactSecrets {
+		var redacted []string
+		content, redacted = redactSecrets(content)
+		if len(redacted) > 0 {
+			a.Log.Warnf("Redacted %d secret(s) in %s: %s", len(redacted), filePath, strings.Join(redacted, ", "))
+		}
+	}
+	if a.MaxFileSize > 0 && len(content) > a.MaxFileSize {
+		a.Log.Warnf("%s is %d bytes, more than the limit of %d; truncating it", filePath, len(content), a.MaxFileSize)
+		content = truncateContent(content, a.MaxFileSize)
+		a.oversizedMu.Lock()
+		a.oversized = append(a.oversized, filePath)
+		a.oversizedMu.Unlock()
+	}
+	return content
+}
+
+// truncateContent cuts content to at most limit bytes at a line boundary
+// and appends a marker saying that the rest was dropped.
+func truncateContent(content string, limit int) string {
+	cut := content[:limit]
+	if i := strings.LastIndexByte(cut, '\n'); i > 0 {
+		cut = cut[:i+1]
+	} else {
+		// Avoid splitting a multi-byte character
+		cut = strings.ToValidUTF8(cut, "")
+	}
+	return cut + fmt.Sprintf("\n[truncated: the generated file exceeded the size limit of %d bytes]\n", limit)
+}
+
+// takeOversized returns and clears the files truncated since the last call.
+func (a *DevAgent) takeOversized() []string {
+	a.oversizedMu.Lock()
+	defer a.oversizedMu.Unlock()
+	oversized := a.oversized
+	a.oversized = nil
+	return oversized
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// previewFile prints the first PreviewLines lines of a generated file.
+func (a *DevAgent) previewFile(filePath, content string) {
+	if a.PreviewLines <= 0 {
+		return
+	}
+
+	lines := strings.Split(content, "\n")
+	shown := lines
+	if len(shown) > a.PreviewLines {
+		shown = shown[:a.PreviewLines]
+	}
+
+	var preview strings.Builder
+	preview.WriteString(fmt.Sprintf("👀 %s:", filePath))
+	for _, line := range shown {
+		preview.WriteString("\n   │ " + line)
+	}
+	if rest := len(lines) - len(shown); rest > 0 {
+		preview.WriteString(fmt.Sprintf("\n   │ … (%d more lines)", rest))
+	}
+	a.Log.Infof("%s", preview.String())
+}
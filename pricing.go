@@ -0,0 +1,134 @@
+package main
+
+import "strings"
+
+// modelPrice is the price in USD per million tokens.
+type modelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// modelPrices lists the list prices of known models. Dated snapshots match
+// by their longest prefix.
+var modelPrices = map[string]modelPrice{
+	"gpt-3.5-turbo": {Input: 0.50, Output: 1.50},
+	"gpt-4":         {Input: 30, Output: 60},
+	"gpt-4-32k":     {Input: 60, Output: 120},
+	"gpt-4-turbo":   {Input: 10, Output: 30},
+	"gpt-4o":        {Input: 2.50, Output: 10},
+	"gpt-4o-mini":   {Input: 0.15, Output: 0.60},
+	"gpt-4.1":       {Input: 2, Output: 8},
+	"gpt-4.1-mini":  {Input: 0.40, Output: 1.60},
+	"o1":            {Input: 15, Output: 60},
+	"o1-mini":       {Input: 1.10, Output: 4.40},
+	"o3":            {Input: 2, Output: 8},
+	"o3-mini":       {Input: 1.10, Output: 4.40},
+	"o4-mini":       {Input: 1.10, Output: 4.40},
+
+	"text-embedding-3-small": {Input: 0.02},
+	"text-embedding-3-large": {Input: 0.13},
+	"text-embedding-ada-002": {Input: 0.10},
+}
+
+// priceFor returns the price of the given model and whether it is known.
+func priceFor(model string) (modelPrice, bool) {
+	best := ""
+	for name := range modelPrices {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return modelPrice{}, false
+	}
+	return modelPrices[best], true
+}
+
+// cost returns the price of the given token counts in USD.
+func (p modelPrice) cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.Input + float64(completionTokens)*p.Output) / 1e6
+}
+
+// Rough sizes used to estimate a generation before it runs
+const (
+	estimatedCodePromptTokens = 300  // code prompt without description and context
+	estimatedFileTokens       = 800  // average generated file
+	estimatedReadmeTokens     = 1200 // generated README
+	estimatedSpecTokens       = 1500 // generated project spec
+)
+
+// estimateRangeFactor scales the average file size down and up to give a
+// cost range, since real files vary widely in length.
+const estimateRangeFactor = 2
+
+// CostEstimate is the projected token usage and price of generating a spec.
+type CostEstimate struct {
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+	// Known is false when a model's price is unknown and Cost is partial
+	Known bool
+}
+
+// EstimateSpecCost projects the cost of generating every file in the spec.
+// Each code prompt includes all previously generated files as context, so
+// prompt size grows with every file.
+func (a *DevAgent) EstimateSpecCost(spec *ProjectSpec) CostEstimate {
+	return a.estimateSpecCost(spec, estimatedFileTokens)
+}
+
+// EstimateSpecCostRange projects the cost of generating the spec for files
+// that are much shorter and much longer than average.
+func (a *DevAgent) EstimateSpecCostRange(spec *ProjectSpec) (low, high CostEstimate) {
+	return a.estimateSpecCost(spec, estimatedFileTokens/estimateRangeFactor),
+		a.estimateSpecCost(spec, estimatedFileTokens*estimateRangeFactor)
+}
+
+// estimateSpecCost projects the cost of the spec assuming every generated
+// file is fileTokens long.
+func (a *DevAgent) estimateSpecCost(spec *ProjectSpec, fileTokens int) CostEstimate {
+	estimate := CostEstimate{Known: true}
+
+	codePrice, ok := priceFor(a.CodeModel)
+	estimate.Known = estimate.Known && ok
+	var codePrompt, codeCompletion int
+	i := 0
+	for _, description := range spec.Files {
+		contextFiles := i
+		if a.ContextTopK > 0 && contextFiles > a.ContextTopK {
+			contextFiles = a.ContextTopK
+		}
+		codePrompt += estimatedCodePromptTokens + estimateTokens(a.CodeModel, description) + contextFiles*fileTokens
+		codeCompletion += fileTokens
+		i++
+	}
+
+	readmePrice, ok := priceFor(a.ReadmeModel)
+	estimate.Known = estimate.Known && ok
+	readmePrompt := estimatedCodePromptTokens + len(spec.Files)*fileTokens
+
+	estimate.PromptTokens = codePrompt + readmePrompt
+	estimate.CompletionTokens = codeCompletion + estimatedReadmeTokens
+	estimate.Cost = codePrice.cost(codePrompt, codeCompletion) + readmePrice.cost(readmePrompt, estimatedReadmeTokens)
+	return estimate
+}
+
+// EstimatePromptCost projects the cost of planning a spec from a project
+// description, for spec sizes between a small and a large project.
+func (a *DevAgent) EstimatePromptCost(prompt string) (low, high CostEstimate) {
+	price, ok := priceFor(a.SpecModel)
+	systemPrompt, err := renderPrompt(a.SpecTemplate, specPromptData{Prompt: prompt, ProfileInstruction: a.specProfileInstruction()})
+	if err != nil {
+		systemPrompt = defaultSpecTemplate
+	}
+	promptTokens := estimateChatTokens(a.SpecModel, systemPrompt, prompt)
+	estimate := func(completionTokens int) CostEstimate {
+		return CostEstimate{
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			Cost:             price.cost(promptTokens, completionTokens),
+			Known:            ok,
+		}
+	}
+	return estimate(estimatedSpecTokens / estimateRangeFactor), estimate(estimatedSpecTokens * estimateRangeFactor)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// Profile adjusts the prompts for a kind of output, e.g. a quick prototype
+// or production-ready code. Each field holds requirement lines.
+type Profile struct {
+	// Spec is added to the requirements of the spec planning prompt
+	Spec string `json:"spec"`
+	// Code is added to the requirements of every code prompt
+	Code string `json:"code"`
+}
+
+// defaultProfiles are the profiles available to -profile; the config file
+// can add more or replace them.
+var defaultProfiles = map[string]Profile{
+	"prototype": {
+		Spec: "Keep the project minimal: only the files a working prototype needs, without tests, CI or deployment configuration",
+		Code: "This is a prototype: favor short, simple code over exhaustive error handling and abstraction",
+	},
+	"production": {
+		Spec: "Plan a production-ready project with tests, configuration, structured error handling, logging and documentation",
+		Code: "This is production code: handle every error, validate inputs, log meaningful events and document exported APIs",
+	},
+}
+
+// profileNames returns the names of the agent's profiles in alphabetical
+// order.
+func (a *DevAgent) profileNames() []string {
+	var names []string
+	for name := range a.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// requirementLines formats text as prompt requirement lines, each starting
+// with "- " and ending in a newline.
+func requirementLines(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "- ") {
+			line = "- " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// specProfileInstruction returns the spec requirement lines of the selected
+// profile, or an empty string.
+func (a *DevAgent) specProfileInstruction() string {
+	return requirementLines(a.Profiles[a.Profile].Spec)
+}
+
+// codeProfileInstruction returns the code requirement lines of the selected
+// profile, or an empty string.
+func (a *DevAgent) codeProfileInstruction() string {
+	return requirementLines(a.Profiles[a.Profile].Code)
+}
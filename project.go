@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// GeneratedProject is a complete generated project held in memory.
+type GeneratedProject struct {
+	Spec *ProjectSpec
+	// Files maps every generated path, including tests and dependency
+	// manifests, to its content
+	Files map[string]string
+	// Readme is the content of README.md, empty with NoReadme
+	Readme string
+	// Usage is the token usage of the whole generation
+	Usage *Usage
+}
+
+// Generate plans a project for the prompt, generates its files, dependency
+// manifests and README, and returns them without writing anything to disk.
+// EnvExample and CommunityFiles add their files to Files. It covers less
+// than GenerateCode, which the command line uses: Makefile, CI,
+// Migrations, GoWorkspace, K8s, Diagram, GoBuild, Incremental and PostHook
+// are ignored. Under KeepGoing a project missing the failed files is
+// returned together with a PartialError.
+func (a *DevAgent) Generate(prompt string) (*GeneratedProject, error) {
+	start := time.Now()
+	a.Usage.Reset()
+
+	spec, err := a.GenerateProjectSpec(prompt)
+	if err != nil {
+		return nil, err
+	}
+	spec = a.named(withoutDependsHints(spec))
+
+	run := &generationRun{
+		agent:       a,
+		spec:        spec,
+		inMemory:    true,
+		baseContext: a.BaseContext,
+		files:       make(map[string]string),
+		manifest:    NewManifest(spec),
+	}
+
+	filePaths := generationOrder(spec)
+	if len(spec.Order) == 0 && len(spec.dependencies) == 0 {
+		groupByLanguage(filePaths)
+	}
+
+	if a.ContextTopK > 0 {
+		run.embeddings, err = a.embedDescriptions(spec, "")
+		if err != nil {
+			a.Log.Warnf("Using all previous files as context: %v", err)
+		}
+	}
+
+	if a.MaxRuntime > 0 {
+		run.deadline = start.Add(a.MaxRuntime)
+	}
+	err = run.generateFiles(filePaths)
+	if errors.Is(err, errMaxRuntime) {
+		return nil, fmt.Errorf("%v after %s: %d of %d file(s) generated", err, a.MaxRuntime, len(run.written), len(filePaths))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, plan := range planDependencyManifests(run.files) {
+		done := a.startFile(plan.Path)
+		content, err := a.generateDependencyManifest(spec, plan)
+		if err != nil {
+			return nil, err
+		}
+		run.files[plan.Path] = content
+		done()
+	}
+
+	// A .env.example the spec planned is kept, as GenerateEnvExample does
+	if _, planned := run.files[envExampleName]; a.EnvExample && !planned {
+		if content := envExample(spec, run.files); content != "" {
+			run.files[envExampleName] = content
+		}
+	}
+
+	if a.CommunityFiles {
+		files, err := communityFiles(spec)
+		if err != nil {
+			return nil, err
+		}
+		for filePath, content := range files {
+			if _, ok := run.files[filePath]; !ok {
+				run.files[filePath] = content
+			}
+		}
+	}
+
+	var readme string
+	if !a.NoReadme {
+		done := a.startFile("README.md")
+		readme, err = a.GenerateReadme(spec, run.files)
+		if err != nil {
+			return nil, err
+		}
+		done()
+	}
+
+	project := &GeneratedProject{
+		Spec:   spec,
+		Files:  run.files,
+		Readme: readme,
+		Usage:  a.Usage.Snapshot(),
+	}
+	if len(run.failed) > 0 {
+		return project, &PartialError{Files: run.failed}
+	}
+	return project, nil
+}
+
+// Write writes the project's files and README, if any, to projectDir.
+func (p *GeneratedProject) Write(projectDir string) error {
+	var filePaths []string
+	for filePath := range p.Files {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+
+	for _, filePath := range filePaths {
+		if err := writeProjectFile(projectDir, filePath, p.Files[filePath]); err != nil {
+			return err
+		}
+	}
+	if p.Readme == "" {
+		return nil
+	}
+	return writeProjectFile(projectDir, "README.md", p.Readme)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// projectCacheOptions are the options that change the prompts or the
+// output generated from a spec, so projects generated with different ones
+// are cached apart.
+type projectCacheOptions struct {
+	Spec             *ProjectSpec
+	CodeModel        string
+	ReadmeModel      string
+	LanguageModels   map[string]string
+	ModelStyles      map[string]ModelStyle
+	SpecTemplate     string
+	CodeTemplate     string
+	Profile          Profile
+	StyleExamples    map[string]string
+	StyleGuide       string
+	CodeSystemPrompt string
+	PromptPrefix     string
+	PromptSuffix     string
+	Header           string
+	Snippets         map[string]string
+	Theme            string
+	Format           bool
+	Extras           []string
+	CI               string
+	Migrations       string
+	GoWorkspace      bool
+	K8sNamespace     string
+	K8sImage         string
+
+	SummaryModel       string
+	ContextUpgrades    map[string]string
+	ReasoningEffort    string
+	Verbosity          string
+	ServiceTier        string
+	ImportBase         string
+	OutputDir          string
+	BaseContext        map[string]string
+	ContextTopK        int
+	EmbeddingModel     string
+	ContextWindow      int
+	BatchFiles         int
+	ChunkLargeFiles    bool
+	MaxFileSize        int
+	RedactSecrets      bool
+	GoBuild            bool
+	FixAttempts        int
+	FixTemperatureStep float32
+}
+
+// projectCacheKey identifies a generated project in the project cache: the
+// hash of the full spec and the options that shape the generated files, so
+// any change to either misses the cache.
+func (a *DevAgent) projectCacheKey(spec *ProjectSpec) (string, error) {
+	data, err := json.Marshal(projectCacheOptions{
+		Spec:             spec,
+		CodeModel:        a.CodeModel,
+		ReadmeModel:      a.ReadmeModel,
+		LanguageModels:   a.LanguageModels,
+		ModelStyles:      a.ModelStyles,
+		SpecTemplate:     templateSource(a.SpecTemplate),
+		CodeTemplate:     templateSource(a.CodeTemplate),
+		Profile:          a.Profiles[a.Profile],
+		StyleExamples:    a.StyleExamples,
+		StyleGuide:       a.StyleGuide,
+		CodeSystemPrompt: a.CodeSystemPrompt,
+		PromptPrefix:     a.PromptPrefix,
+		PromptSuffix:     a.PromptSuffix,
+		Header:           a.Header,
+		Snippets:         a.Snippets,
+		Theme:            a.Theme,
+		Format:           a.Format,
+		Extras:           a.enabledExtras(),
+		CI:               a.CI,
+		Migrations:       a.Migrations,
+		GoWorkspace:      a.GoWorkspace,
+		K8sNamespace:     a.K8sNamespace,
+		K8sImage:         a.K8sImage,
+
+		SummaryModel:       a.SummaryModel,
+		ContextUpgrades:    a.ContextUpgrades,
+		ReasoningEffort:    a.ReasoningEffort,
+		Verbosity:          a.Verbosity,
+		ServiceTier:        a.ServiceTier,
+		ImportBase:         a.ImportBase,
+		OutputDir:          a.OutputDir,
+		BaseContext:        a.BaseContext,
+		ContextTopK:        a.ContextTopK,
+		EmbeddingModel:     string(a.EmbeddingModel),
+		ContextWindow:      a.ContextWindow,
+		BatchFiles:         a.BatchFiles,
+		ChunkLargeFiles:    a.ChunkLargeFiles,
+		MaxFileSize:        a.MaxFileSize,
+		RedactSecrets:      a.RedactSecrets,
+		GoBuild:            a.GoBuild,
+		FixAttempts:        a.FixAttempts,
+		FixTemperatureStep: a.FixTemperatureStep,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode project spec: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// templateSource returns the text of a parsed prompt template, or "" if
+// there is none.
+func templateSource(tmpl *template.Template) string {
+	if tmpl == nil || tmpl.Tree == nil {
+		return ""
+	}
+	return tmpl.Tree.Root.String()
+}
+
+// restoreCachedProject copies the project cached for spec into projectDir.
+// It returns the restored manifest, or nil if the spec is not cached.
+func (a *DevAgent) restoreCachedProject(spec *ProjectSpec, projectDir string) (*Manifest, error) {
+	key, err := a.projectCacheKey(spec)
+	if err != nil {
+		return nil, err
+	}
+	cacheDir := filepath.Join(a.ProjectCacheDir, key)
+	manifest, err := ReadManifest(cacheDir)
+	if err != nil || manifest == nil {
+		return nil, err
+	}
+
+	// As with generated files, existing files are kept under SkipExisting
+	// and manual regions survive
+	run := &generationRun{agent: a, spec: spec, projectDir: projectDir}
+	for _, entry := range manifest.Files {
+		content, err := os.ReadFile(filepath.Join(cacheDir, entry.Path))
+		if err != nil {
+			return nil, describeFSError("read cached", entry.Path, err)
+		}
+		if a.keepExisting(projectDir, entry.Path) {
+			continue
+		}
+		if err := writeProjectFile(projectDir, entry.Path, run.keepManualEdits(entry.Path, string(content))); err != nil {
+			return nil, err
+		}
+	}
+	if err := manifest.Write(projectDir); err != nil {
+		return nil, err
+	}
+	a.Log.Infof("📦 Restored %d file(s) from the project cache", len(manifest.Files))
+	return manifest, nil
+}
+
+// cacheProject stores the files of the manifest, as written to projectDir,
+// in the project cache. The entry is assembled in a temporary directory and
+// renamed into place, so an interrupted run never leaves a partial entry.
+// Failures are reported as warnings since the project itself is complete.
+func (a *DevAgent) cacheProject(spec *ProjectSpec, projectDir string, manifest *Manifest) {
+	key, err := a.projectCacheKey(spec)
+	if err != nil {
+		a.Log.Warnf("Could not cache the project: %v", err)
+		return
+	}
+	if err := os.MkdirAll(a.ProjectCacheDir, 0755); err != nil {
+		a.Log.Warnf("Could not cache the project: %v", describeFSError("create project cache", a.ProjectCacheDir, err))
+		return
+	}
+	tmpDir, err := os.MkdirTemp(a.ProjectCacheDir, key+"-*")
+	if err != nil {
+		a.Log.Warnf("Could not cache the project: %v", describeFSError("create project cache entry in", a.ProjectCacheDir, err))
+		return
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, entry := range manifest.Files {
+		content, err := os.ReadFile(filepath.Join(projectDir, entry.Path))
+		if err == nil {
+			err = writeProjectFile(tmpDir, entry.Path, string(content))
+		}
+		if err != nil {
+			a.Log.Warnf("Could not cache the project: %v", err)
+			return
+		}
+	}
+	if err := manifest.Write(tmpDir); err != nil {
+		a.Log.Warnf("Could not cache the project: %v", err)
+		return
+	}
+
+	cacheDir := filepath.Join(a.ProjectCacheDir, key)
+	if err := os.RemoveAll(cacheDir); err != nil {
+		a.Log.Warnf("Could not cache the project: %v", describeFSError("replace project cache entry", cacheDir, err))
+		return
+	}
+	if err := os.Rename(tmpDir, cacheDir); err != nil {
+		a.Log.Warnf("Could not cache the project: %v", describeFSError("store project cache entry", cacheDir, err))
+		return
+	}
+	a.Log.Debugf("Cached the project in %s", cacheDir)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectCacheKey(t *testing.T) {
+	base := func() *DevAgent {
+		return &DevAgent{CodeModel: "gpt-4o", CodeTemplate: defaultCodeTmpl}
+	}
+	want, err := base().projectCacheKey(validSpec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := base().projectCacheKey(validSpec()); got != want {
+		t.Errorf("the same spec and options gave keys %s and %s", want, got)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(a *DevAgent)
+	}{
+		{"code model", func(a *DevAgent) { a.CodeModel = "gpt-4o-mini" }},
+		{"tests", func(a *DevAgent) { a.WithTests = true }},
+		{"no readme", func(a *DevAgent) { a.NoReadme = true }},
+		{"makefile", func(a *DevAgent) { a.Makefile = true }},
+		{"ci", func(a *DevAgent) { a.CI = "github" }},
+		{"header", func(a *DevAgent) { a.Header = "Copyright" }},
+		{"format", func(a *DevAgent) { a.Format = true }},
+		{"style guide", func(a *DevAgent) { a.StyleGuide = "Use tabs" }},
+		{"language model", func(a *DevAgent) { a.LanguageModels = map[string]string{"go": "o3"} }},
+		{"code template", func(a *DevAgent) { a.CodeTemplate = defaultSpecTmpl }},
+		{"output dir", func(a *DevAgent) { a.OutputDir = "services" }},
+		{"base context", func(a *DevAgent) { a.BaseContext = map[string]string{"go.mod": "module x"} }},
+		{"max file size", func(a *DevAgent) { a.MaxFileSize = 1000 }},
+		{"redact secrets", func(a *DevAgent) { a.RedactSecrets = true }},
+		{"reasoning effort", func(a *DevAgent) { a.ReasoningEffort = "high" }},
+		{"context window", func(a *DevAgent) { a.ContextWindow = 3 }},
+		{"batch files", func(a *DevAgent) { a.BatchFiles = 4 }},
+		{"chunk large files", func(a *DevAgent) { a.ChunkLargeFiles = true }},
+		{"go build", func(a *DevAgent) { a.GoBuild = true }},
+		{"profile", func(a *DevAgent) {
+			a.Profile = "prototype"
+			a.Profiles = defaultProfiles
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := base()
+			tt.modify(a)
+			got, err := a.projectCacheKey(validSpec())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == want {
+				t.Errorf("changing the %s kept the key %s", tt.name, got)
+			}
+		})
+	}
+}
+
+func TestRestoreCachedProjectKeepsLocalFiles(t *testing.T) {
+	spec := validSpec()
+	cached := "package main\n\n// BEGIN MANUAL\nfunc cached() {}\n// END MANUAL\n"
+	local := "package main\n\n// BEGIN MANUAL\nfunc mine() {}\n// END MANUAL\n"
+
+	a := &DevAgent{CodeModel: "gpt-4o", ProjectCacheDir: t.TempDir(), Log: NewLogger()}
+	projectDir := t.TempDir()
+	writeTestFile(t, projectDir, "main.go", cached)
+	manifest := NewManifest(spec)
+	manifest.Files = append(manifest.Files, ManifestEntry{Path: "main.go", Kind: fileKindSource})
+	a.cacheProject(spec, projectDir, manifest)
+
+	tests := []struct {
+		name         string
+		skipExisting bool
+		want         string
+	}{
+		{"manual regions", false, local},
+		{"skip existing", true, "package main // mine\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a.SkipExisting = tt.skipExisting
+			projectDir := t.TempDir()
+			writeTestFile(t, projectDir, "main.go", tt.want)
+			if _, err := a.restoreCachedProject(spec, projectDir); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := os.ReadFile(filepath.Join(projectDir, "main.go")); string(got) != tt.want {
+				t.Errorf("main.go = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, dir, filePath, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, filePath), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
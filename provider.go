@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Provider sends chat completion requests to a model backend.
+type Provider interface {
+	Name() string
+	CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+}
+
+// providerNames lists the providers that can be passed to -providers.
+var providerNames = []string{"openai", "azure", "openrouter", "ollama"}
+
+// compatibleProvider talks to any backend that implements the OpenAI chat
+// completions API.
+type compatibleProvider struct {
+	name   string
+	client *openai.Client
+	// model replaces the requested model when set, for backends that do
+	// not serve OpenAI models
+	model string
+	// modelPrefix is prepended to model names without a vendor prefix
+	modelPrefix string
+}
+
+func (p *compatibleProvider) Name() string {
+	return p.name
+}
+
+func (p *compatibleProvider) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	if p.model != "" {
+		req.Model = p.model
+	} else if p.modelPrefix != "" && !strings.Contains(req.Model, "/") {
+		req.Model = p.modelPrefix + req.Model
+	}
+	return p.client.CreateChatCompletion(ctx, req)
+}
+
+// newClient creates an API client for config that sends its requests
+// through httpClient, or the library default if httpClient is nil.
+func newClient(config openai.ClientConfig, httpClient *http.Client) *openai.Client {
+	if httpClient != nil {
+		config.HTTPClient = httpClient
+	}
+	return openai.NewClientWithConfig(config)
+}
+
+// newProvider creates a provider from an entry of the -providers list,
+// which is a provider name optionally followed by =model to use that
+// model for every request sent to it, e.g. "ollama=llama3.1". Credentials
+// other than the OpenAI key are read from the environment.
+func newProvider(entry, openAIKey string, httpClient *http.Client) (Provider, error) {
+	name, model, _ := strings.Cut(strings.TrimSpace(entry), "=")
+	name = strings.TrimSpace(name)
+	model = strings.TrimSpace(model)
+
+	switch name {
+	case "openai":
+		if openAIKey == "" {
+			return nil, fmt.Errorf("provider openai needs an API key via -api-key or OPENAI_API_KEY")
+		}
+		return &compatibleProvider{name: name, client: newClient(openai.DefaultConfig(openAIKey), httpClient), model: model}, nil
+	case "azure":
+		key, endpoint := os.Getenv("AZURE_OPENAI_API_KEY"), os.Getenv("AZURE_OPENAI_ENDPOINT")
+		if key == "" || endpoint == "" {
+			return nil, fmt.Errorf("provider azure needs AZURE_OPENAI_API_KEY and AZURE_OPENAI_ENDPOINT")
+		}
+		return &compatibleProvider{name: name, client: newClient(openai.DefaultAzureConfig(key, endpoint), httpClient), model: model}, nil
+	case "openrouter":
+		key := os.Getenv("OPENROUTER_API_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("provider openrouter needs OPENROUTER_API_KEY")
+		}
+		config := openai.DefaultConfig(key)
+		config.BaseURL = "https://openrouter.ai/api/v1"
+		return &compatibleProvider{name: name, client: newClient(config, httpClient), model: model, modelPrefix: "openai/"}, nil
+	case "ollama":
+		if model == "" {
+			return nil, fmt.Errorf("provider ollama needs a model, e.g. ollama=llama3.1")
+		}
+		host := os.Getenv("OLLAMA_HOST")
+		if host == "" {
+			host = "http://localhost:11434"
+		}
+		config := openai.DefaultConfig("ollama")
+		config.BaseURL = strings.TrimSuffix(host, "/") + "/v1"
+		return &compatibleProvider{name: name, client: newClient(config, httpClient), model: model}, nil
+	}
+	return nil, fmt.Errorf("unknown provider %q (valid providers: %s)", name, strings.Join(providerNames, ", "))
+}
+
+// parseProviders creates the providers of a comma-separated -providers
+// list, in fallback order. All of them send requests through httpClient
+// unless it is nil.
+func parseProviders(value, openAIKey string, httpClient *http.Client) ([]Provider, error) {
+	var providers []Provider
+	for _, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		provider, err := newProvider(entry, openAIKey, httpClient)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no providers given")
+	}
+	return providers, nil
+}
+
+// recordProvider remembers which provider produced the output for label.
+func (a *DevAgent) recordProvider(label, provider string) {
+	a.producedBy.Store(label, provider)
+}
+
+// ProviderFor returns the name of the provider that produced the output
+// for label, e.g. a file path.
+func (a *DevAgent) ProviderFor(label string) string {
+	if provider, ok := a.producedBy.Load(label); ok {
+		return provider.(string)
+	}
+	return ""
+}
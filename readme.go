@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// RegenerateReadme regenerates only the README.md of an existing project
+// from the files on disk, leaving all other files untouched. spec supplies
+// the project's name, description and framework; when it is nil they are
+// taken from the project's manifest, or the directory name.
+func (a *DevAgent) RegenerateReadme(projectDir string, spec *ProjectSpec) error {
+	files, err := loadContextDir(projectDir)
+	if err != nil {
+		return err
+	}
+	delete(files, "README.md")
+	if len(files) == 0 {
+		return fmt.Errorf("no project files found in %s", projectDir)
+	}
+
+	manifest, err := ReadManifest(projectDir)
+	if err != nil {
+		return err
+	}
+	if spec == nil {
+		spec = &ProjectSpec{Name: filepath.Base(filepath.Clean(projectDir))}
+		if manifest != nil {
+			spec.Name = manifest.Name
+			spec.Framework = manifest.Framework
+		}
+	}
+
+	a.Log.Infof("📄 Regenerating README.md from %d file(s) in %s", len(files), projectDir)
+	done := a.startFile("README.md")
+	readme, err := a.GenerateReadme(spec, files)
+	if err != nil {
+		return err
+	}
+	err = writeProjectFile(projectDir, "README.md", readme)
+	if err != nil {
+		return err
+	}
+	done()
+
+	if manifest != nil {
+		manifest.Add("README.md", fileKindReadme).Provider = a.ProviderFor("README.md")
+		if err := manifest.Write(projectDir); err != nil {
+			return err
+		}
+	}
+	a.Log.Infof("✨ README.md regenerated!")
+	return nil
+}
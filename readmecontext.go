@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// defaultSummaryModel is the cheap model that summarizes files which do
+// not fit a prompt.
+const defaultSummaryModel = openai.GPT4oMini
+
+// readmeContextRatio is the share of the README model's context that the
+// project's files may take up in the README prompt.
+const readmeContextRatio = 0.6
+
+// summarizeFile returns a short summary of a file's purpose and public
+// interface. Summaries are cached by path and content, so a file is only
+// summarized once per agent.
+func (a *DevAgent) summarizeFile(filePath, content string) (string, error) {
+	key := descriptionHash(filePath + "\n" + content)
+	a.summariesMu.Lock()
+	summary, ok := a.summaries[key]
+	a.summariesMu.Unlock()
+	if ok {
+		return summary, nil
+	}
+
+	summary, err := a.chat(chatRequest{
+		Label:       filePath,
+		Step:        "summary",
+		Model:       a.SummaryModel,
+		System:      "You are an expert programmer who writes concise technical summaries.",
+		Prompt:      fmt.Sprintf("Summarize the file %s in at most five sentences: its purpose, what it exports or exposes, and how it is run or used. Respond only with the summary.\n\n```\n%s\n```", filePath, content),
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize %s: %v", filePath, err)
+	}
+	summary = strings.TrimSpace(summary)
+
+	a.summariesMu.Lock()
+	if a.summaries == nil {
+		a.summaries = make(map[string]string)
+	}
+	a.summaries[key] = summary
+	a.summariesMu.Unlock()
+	return summary, nil
+}
+
+// readmeFiles returns the files to describe in the README prompt. When the
+// files exceed the README model's context budget, the largest are replaced
+// by summaries until the rest fits; the returned set holds the summarized
+// paths. A file that cannot be summarized is listed by path only.
+func (a *DevAgent) readmeFiles(generatedFiles map[string]string) (map[string]string, map[string]bool) {
+	files := make(map[string]string, len(generatedFiles))
+	total := 0
+	for filePath, content := range generatedFiles {
+		files[filePath] = content
+		total += estimateTokens(a.ReadmeModel, content)
+	}
+	summarized := make(map[string]bool)
+	budget := int(float64(contextLimit(a.ReadmeModel)) * readmeContextRatio)
+	if total <= budget {
+		return files, summarized
+	}
+
+	paths := make([]string, 0, len(files))
+	for filePath := range files {
+		paths = append(paths, filePath)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if len(files[paths[i]]) != len(files[paths[j]]) {
+			return len(files[paths[i]]) > len(files[paths[j]])
+		}
+		return paths[i] < paths[j]
+	})
+
+	a.Log.Infof("📉 Project files exceed the README context budget (~%d of %d tokens), summarizing the largest", total, budget)
+	for _, filePath := range paths {
+		if total <= budget {
+			break
+		}
+		content := files[filePath]
+		summary, err := a.summarizeFile(filePath, content)
+		if err != nil {
+			a.Log.Warnf("Listing %s without its content: %v", filePath, err)
+		}
+		total -= estimateTokens(a.ReadmeModel, content) - estimateTokens(a.ReadmeModel, summary)
+		files[filePath] = summary
+		summarized[filePath] = true
+	}
+	return files, summarized
+}
+
+// describeProjectFiles renders files for the README prompt, sorted by
+// path; summarized files are marked as such.
+func describeProjectFiles(files map[string]string, summarized map[string]bool) string {
+	paths := make([]string, 0, len(files))
+	for filePath := range files {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, filePath := range paths {
+		switch {
+		case !summarized[filePath]:
+			b.WriteString(fmt.Sprintf("\n%s:\n```\n%s\n```\n", filePath, files[filePath]))
+		case files[filePath] != "":
+			b.WriteString(fmt.Sprintf("\n%s (summary):\n%s\n", filePath, files[filePath]))
+		default:
+			b.WriteString(fmt.Sprintf("\n%s\n", filePath))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// defaultMaxRetries is how often a failed API call is retried by default.
+const defaultMaxRetries = 3
+
+// defaultFixAttempts is how often invalid output is sent back for a fix.
+const defaultFixAttempts = 1
+
+// baseTemperature is the temperature of generation requests.
+const baseTemperature = 0.2
+
+// defaultFixTemperatureStep is how much the temperature rises with every
+// fix attempt, so that a fix does not repeat the rejected output.
+const defaultFixTemperatureStep = 0.1
+
+// maxFixTemperature caps the temperature of fix attempts.
+const maxFixTemperature = 1.0
+
+// fixTemperature returns the temperature of the given fix attempt,
+// counting from 1: the base temperature raised by FixTemperatureStep per
+// attempt, up to maxFixTemperature.
+func (a *DevAgent) fixTemperature(attempt int) float32 {
+	temperature := baseTemperature + a.FixTemperatureStep*float32(attempt)
+	if temperature > maxFixTemperature {
+		return maxFixTemperature
+	}
+	return temperature
+}
+
+// retryBaseDelay is the wait before the first retry; it doubles with every
+// further attempt.
+const retryBaseDelay = 2 * time.Second
+
+// httpStatus returns the HTTP status code carried by an API error, or 0.
+func httpStatus(err error) int {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode
+	}
+	return 0
+}
+
+// defaultRetryStatuses are the HTTP statuses retried unless -retry-statuses
+// sets others: rate limits and server errors.
+const defaultRetryStatuses = "429,5xx"
+
+// parseRetryStatuses parses a comma-separated list of HTTP status codes,
+// such as 408, and classes, such as 5xx, into the set of codes it covers.
+func parseRetryStatuses(value string) (map[int]bool, error) {
+	statuses := make(map[int]bool)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if class, ok := strings.CutSuffix(field, "xx"); ok && len(class) == 1 && class >= "1" && class <= "5" {
+			first := int(class[0]-'0') * 100
+			for status := first; status < first+100; status++ {
+				statuses[status] = true
+			}
+			continue
+		}
+		status, err := strconv.Atoi(field)
+		if err != nil || status < 100 || status > 599 {
+			return nil, fmt.Errorf("invalid status %q: use codes from 100 to 599 or classes such as 5xx", field)
+		}
+		statuses[status] = true
+	}
+	return statuses, nil
+}
+
+// formatRetryStatuses lists statuses the way -retry-statuses takes them,
+// with complete classes such as 5xx collapsed; nil is the default.
+func formatRetryStatuses(statuses map[int]bool) []string {
+	if statuses == nil {
+		statuses, _ = parseRetryStatuses(defaultRetryStatuses)
+	}
+	var fields []string
+	for class := 1; class <= 5; class++ {
+		first := class * 100
+		complete := true
+		for status := first; status < first+100; status++ {
+			complete = complete && statuses[status]
+		}
+		if complete {
+			fields = append(fields, fmt.Sprintf("%dxx", class))
+			continue
+		}
+		for status := first; status < first+100; status++ {
+			if statuses[status] {
+				fields = append(fields, strconv.Itoa(status))
+			}
+		}
+	}
+	return fields
+}
+
+// isRetryable reports whether the error is transient: an HTTP status in
+// RetryStatuses (rate limits and server errors unless set), a network
+// timeout or an empty response.
+func (a *DevAgent) isRetryable(err error) bool {
+	if status := httpStatus(err); status != 0 {
+		if a.RetryStatuses == nil {
+			return status == 429 || status >= 500
+		}
+		return a.RetryStatuses[status]
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, errEmptyResponse)
+}
+
+// isBillable reports whether a failed attempt may still have been charged.
+// Rate-limited requests are rejected before the model runs.
+func isBillable(err error) bool {
+	return httpStatus(err) != 429
+}
+
+// retryDelay returns the backoff before the given retry (starting at 1).
+func retryDelay(retry int) time.Duration {
+	return retryBaseDelay << (retry - 1)
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SpecReview is the model's critique of a project spec.
+type SpecReview struct {
+	Summary     string       `json:"summary"`
+	Issues      []string     `json:"issues"`
+	RevisedSpec *ProjectSpec `json:"revised_spec,omitempty"`
+}
+
+// ReviewSpec asks the model to critique the spec for missing files,
+// security concerns and architecture issues. When it has concrete
+// improvements, the review includes a revised spec that can be applied.
+func (a *DevAgent) ReviewSpec(spec *ProjectSpec) (*SpecReview, error) {
+	specJSON, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode project spec: %v", err)
+	}
+
+	systemPrompt := `You are a senior software architect reviewing a project plan before any code is written.
+Critique the project specification and look for:
+- Missing files needed for a working, production ready project
+- Security concerns
+- Architecture and framework issues
+- Inconsistencies between the description, components and files
+
+Respond only with valid JSON in the following structure:
+{
+  "summary": "<one paragraph overall assessment>",
+  "issues": [
+    "<issue 1 and how to fix it>",
+    ...
+  ],
+  "revised_spec": <the full corrected project specification in the same structure as the input, or null if no changes are needed>
+}`
+
+	content, err := a.chat(chatRequest{
+		Label:       "spec review",
+		Model:       a.SpecModel,
+		System:      systemPrompt,
+		Prompt:      string(specJSON),
+		Temperature: 0.2,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to review project spec: %v", err)
+	}
+
+	var review SpecReview
+	err = a.parseModelJSON(content, &review)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse spec review: %v", err)
+	}
+
+	return &review, nil
+}
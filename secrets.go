@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// secretPattern recognizes a known kind of credential.
+type secretPattern struct {
+	Kind        string
+	Placeholder string
+	Pattern     *regexp.Regexp
+}
+
+var secretPatterns = []secretPattern{
+	{"private key", "REDACTED_PRIVATE_KEY", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{"OpenAI API key", "REDACTED_OPENAI_API_KEY", regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_-]{20,}`)},
+	{"AWS access key", "REDACTED_AWS_ACCESS_KEY", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", "REDACTED_GITHUB_TOKEN", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{"Slack token", "REDACTED_SLACK_TOKEN", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"Stripe key", "REDACTED_STRIPE_KEY", regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[A-Za-z0-9]{16,}`)},
+	{"Google API key", "REDACTED_GOOGLE_API_KEY", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{"JWT", "REDACTED_JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+}
+
+var (
+	// secretAssignment matches a quoted literal on a line that names it a
+	// key, secret, token or password
+	secretAssignment = regexp.MustCompile(`(?i)(?:key|secret|token|passw(?:or)?d|pwd|credential)\w*["']?\s*[:=]\s*["']([A-Za-z0-9+/=_\-.!@#$%^&*]{16,})["']`)
+)
+
+// minSecretEntropy is the Shannon entropy, in bits per character, above
+// which a literal assigned to a secret-looking name is treated as a secret.
+const minSecretEntropy = 3.5
+
+// shannonEntropy returns the entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var entropy float64
+	n := float64(len([]rune(s)))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// redactSecrets replaces credentials in content with clearly marked
+// placeholders. It returns the redacted content and the kind of each
+// redacted secret.
+func redactSecrets(content string) (string, []string) {
+	var redacted []string
+	for _, p := range secretPatterns {
+		content = p.Pattern.ReplaceAllStringFunc(content, func(string) string {
+			redacted = append(redacted, p.Kind)
+			return p.Placeholder
+		})
+	}
+
+	content = secretAssignment.ReplaceAllStringFunc(content, func(match string) string {
+		value := secretAssignment.FindStringSubmatch(match)[1]
+		if strings.HasPrefix(value, "REDACTED_") || shannonEntropy(value) < minSecretEntropy {
+			return match
+		}
+		redacted = append(redacted, "high-entropy secret")
+		return strings.Replace(match, value, "REDACTED_SECRET", 1)
+	})
+	return content, redacted
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// selfTestFiles are the files of the self-test project. They are plain web
+// files so no dependency manifest has to be generated.
+var selfTestFiles = map[string]string{
+	"index.html": "A minimal HTML page that links style.css and shows the heading Hello",
+	"style.css":  "A single rule that centers the h1 heading",
+}
+
+// SelfTest generates a tiny fixed project in a temporary directory to check
+// that the configured providers and models work end to end. The project is
+// removed afterwards.
+func (a *DevAgent) SelfTest() error {
+	dir, err := os.MkdirTemp("", "ashutosh-selftest-")
+	if err != nil {
+		return describeFSError("create temporary directory", os.TempDir(), err)
+	}
+	defer os.RemoveAll(dir)
+
+	spec := &ProjectSpec{
+		Name:        "selftest",
+		Type:        "web",
+		Framework:   "HTML",
+		Files:       make(map[string]string, len(selfTestFiles)),
+		Description: "A one-page site used to smoke-test the generator",
+	}
+	for filePath, description := range selfTestFiles {
+		spec.Files[filePath] = description
+	}
+
+	a.OutputDir = dir
+	a.Usage.Reset()
+	start := time.Now()
+	err = a.generateCode(spec, a.ProjectDir(spec))
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		return fmt.Errorf("self-test failed after %s: %v", elapsed, err)
+	}
+
+	var filePaths []string
+	for filePath := range spec.Files {
+		filePaths = append(filePaths, filePath)
+	}
+	filePaths = append(filePaths, "README.md")
+	sort.Strings(filePaths)
+	for _, filePath := range filePaths {
+		info, err := os.Stat(filepath.Join(a.ProjectDir(spec), filePath))
+		if err != nil || info.Size() == 0 {
+			return fmt.Errorf("self-test failed after %s: %s was not generated", elapsed, filePath)
+		}
+	}
+
+	a.Log.Infof("✅ Self-test passed in %s (%s)", elapsed, a.Usage.Summary())
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// snippetMarker matches a line that references a shared snippet, whatever
+// comment syntax the language uses around it.
+var snippetMarker = regexp.MustCompile(`(?m)^[^\n]*@snippet\s+([\w.-]+)[^\n]*$`)
+
+// loadSnippets reads every file in dir as a shared snippet named after the
+// file without its extension.
+func loadSnippets(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snippets directory: %v", err)
+	}
+
+	snippets := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snippet %s: %v", entry.Name(), err)
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		snippets[name] = strings.TrimRight(string(data), "\n")
+	}
+	return snippets, nil
+}
+
+// snippets merges the snippets declared in the spec with those configured on
+// the agent. Configured snippets win when both use the same name.
+func (a *DevAgent) snippets(spec *ProjectSpec) map[string]string {
+	if len(spec.Snippets) == 0 {
+		return a.Snippets
+	}
+	merged := make(map[string]string, len(spec.Snippets)+len(a.Snippets))
+	for name, content := range spec.Snippets {
+		merged[name] = content
+	}
+	for name, content := range a.Snippets {
+		merged[name] = content
+	}
+	return merged
+}
+
+// snippetInstruction returns prompt text that shows the model the shared
+// snippets and asks it to reference them instead of writing them out.
+func (a *DevAgent) snippetInstruction(spec *ProjectSpec) string {
+	snippets := a.snippets(spec)
+	if len(snippets) == 0 {
+		return ""
+	}
+
+	var names []string
+	for name := range snippets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("- Shared snippets are inserted automatically: where this file needs one, write a single comment line containing only @snippet <name> instead of its content\n")
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("\nSnippet %s:\n```\n%s\n```\n", name, snippets[name]))
+	}
+	return b.String()
+}
+
+// spliceSnippets replaces snippet reference lines with the snippet content.
+// References to unknown snippets are left in place and reported.
+func (a *DevAgent) spliceSnippets(spec *ProjectSpec, filePath, content string) string {
+	snippets := a.snippets(spec)
+	if len(snippets) == 0 {
+		return content
+	}
+	return snippetMarker.ReplaceAllStringFunc(content, func(line string) string {
+		name := snippetMarker.FindStringSubmatch(line)[1]
+		snippet, ok := snippets[name]
+		if !ok {
+			a.Log.Warnf("%s references unknown snippet %q", filePath, name)
+			return line
+		}
+		return snippet
+	})
+}
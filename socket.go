@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// socketEvent is one Observer event sent as a JSON line over the socket.
+type socketEvent struct {
+	Time       string `json:"time"`
+	Event      string `json:"event"`
+	Path       string `json:"path"`
+	Tokens     int    `json:"tokens,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// socketObserver sends generation events as JSON lines to every client
+// connected to a Unix domain socket, e.g. an editor plugin rendering
+// progress. Clients that connect late only receive later events, and a
+// client that cannot keep up is dropped.
+type socketObserver struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	clients []*socketClient
+	closed  bool
+	// writers tracks the goroutine of each client, so Close can wait for
+	// the events already queued
+	writers sync.WaitGroup
+}
+
+// socketClient is a connected client with the events queued for it, which
+// its own goroutine writes, so a slow client never holds up generation.
+type socketClient struct {
+	conn   net.Conn
+	events chan []byte
+}
+
+// socketWriteTimeout is how long an event may take to reach a client.
+const socketWriteTimeout = time.Second
+
+// socketClientBuffer is how many events may be queued for a client before
+// it is dropped.
+const socketClientBuffer = 256
+
+// listenSocket listens on a Unix domain socket at path for clients of
+// generation events. A socket left behind by a run that was killed is
+// replaced.
+func listenSocket(path string) (*socketObserver, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another run is listening on %s", path)
+		}
+		os.Remove(path)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, describeFSError("listen on socket", path, err)
+	}
+
+	o := &socketObserver{listener: listener}
+	go o.accept()
+	return o, nil
+}
+
+// accept adds connecting clients until the listener is closed.
+func (o *socketObserver) accept() {
+	for {
+		conn, err := o.listener.Accept()
+		if err != nil {
+			return
+		}
+		client := &socketClient{conn: conn, events: make(chan []byte, socketClientBuffer)}
+		o.mu.Lock()
+		if o.closed {
+			o.mu.Unlock()
+			conn.Close()
+			return
+		}
+		o.clients = append(o.clients, client)
+		o.writers.Add(1)
+		o.mu.Unlock()
+		go o.write(client)
+	}
+}
+
+// write sends the events queued for client until its queue is closed or a
+// write fails, then disconnects it.
+func (o *socketObserver) write(client *socketClient) {
+	defer o.writers.Done()
+	defer client.conn.Close()
+	for data := range client.events {
+		client.conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout))
+		if _, err := client.conn.Write(data); err != nil {
+			o.mu.Lock()
+			o.drop(client)
+			o.mu.Unlock()
+			return
+		}
+	}
+}
+
+// drop removes client, if it is still connected, and closes its queue.
+// The caller holds o.mu.
+func (o *socketObserver) drop(client *socketClient) {
+	for i, c := range o.clients {
+		if c == client {
+			o.clients = append(o.clients[:i], o.clients[i+1:]...)
+			close(client.events)
+			return
+		}
+	}
+}
+
+// send queues event for all clients, dropping those whose queue is full.
+func (o *socketObserver) send(event socketEvent) {
+	event.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, client := range append([]*socketClient(nil), o.clients...) {
+		select {
+		case client.events <- data:
+		default:
+			o.drop(client)
+		}
+	}
+}
+
+func (o *socketObserver) OnFileStart(path string) {
+	o.send(socketEvent{Event: "file_start", Path: path})
+}
+
+func (o *socketObserver) OnFileDone(path string, tokens int, duration time.Duration) {
+	o.send(socketEvent{Event: "file_done", Path: path, Tokens: tokens, DurationMs: duration.Milliseconds()})
+}
+
+func (o *socketObserver) OnError(path string, err error) {
+	o.send(socketEvent{Event: "error", Path: path, Error: err.Error()})
+}
+
+// Close stops listening, which removes the socket, and disconnects all
+// clients once the events queued for them are written.
+func (o *socketObserver) Close() error {
+	err := o.listener.Close()
+	o.mu.Lock()
+	o.closed = true
+	for _, client := range o.clients {
+		close(client.events)
+	}
+	o.clients = nil
+	o.mu.Unlock()
+	o.writers.Wait()
+	return err
+}
+
+// multiObserver passes each event on to all of its observers in order.
+type multiObserver []Observer
+
+func (m multiObserver) OnFileStart(path string) {
+	for _, o := range m {
+		o.OnFileStart(path)
+	}
+}
+
+func (m multiObserver) OnFileDone(path string, tokens int, duration time.Duration) {
+	for _, o := range m {
+		o.OnFileDone(path, tokens, duration)
+	}
+}
+
+func (m multiObserver) OnError(path string, err error) {
+	for _, o := range m {
+		o.OnError(path, err)
+	}
+}
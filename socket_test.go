@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSocketObserverStalledClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.sock")
+	o, err := listenSocket(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A client that never reads, and one that does
+	stalled, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stalled.Close()
+	reader, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	for deadline := time.Now().Add(time.Second); ; {
+		o.mu.Lock()
+		n := len(o.clients)
+		o.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of 2 clients connected", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	received := make(chan int)
+	go func() {
+		lines := 0
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			lines++
+		}
+		received <- lines
+	}()
+
+	const events = 5000
+	start := time.Now()
+	for i := 0; i < events; i++ {
+		o.OnFileStart("some/long/path/to/a/generated/file/that/fills/the/socket/buffer.go")
+	}
+	if elapsed := time.Since(start); elapsed > socketWriteTimeout/2 {
+		t.Errorf("sending %d events took %s with a stalled client", events, elapsed)
+	}
+
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if lines := <-received; lines == 0 {
+		t.Error("the reading client received no events")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// loadSpecFile reads a project spec from a JSON file, such as one saved
+// from an earlier run.
+func loadSpecFile(path string) (*ProjectSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, describeFSError("read spec file", path, err)
+	}
+
+	return parseSpec(data, path)
+}
+
+// readSpecStdin reads a project spec piped on stdin, e.g. built with jq.
+func readSpecStdin(r io.Reader) (*ProjectSpec, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec from stdin: %v", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("no spec was piped on stdin")
+	}
+	return parseSpec(data, "stdin")
+}
+
+// pipedSpec reports whether the input starts with a JSON object, which
+// tells a piped spec apart from piped project descriptions.
+func pipedSpec(r *bufio.Reader) bool {
+	for i := 1; ; i++ {
+		data, err := r.Peek(i)
+		if len(data) < i {
+			return false
+		}
+		switch data[i-1] {
+		case ' ', '\t', '\r', '\n':
+			if err != nil {
+				return false
+			}
+			continue
+		case '{':
+			return true
+		}
+		return false
+	}
+}
+
+// parseSpec decodes and validates a project spec read from source.
+func parseSpec(data []byte, source string) (*ProjectSpec, error) {
+	var spec ProjectSpec
+	err := json.Unmarshal(data, &spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse spec from %s: %v", source, err)
+	}
+
+	err = validateSpec(&spec)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", source, err)
+	}
+	return &spec, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// projectSpecSchema is the JSON schema a planned ProjectSpec must match.
+// Unlike decoding into the struct, it reports fields of the wrong type,
+// missing fields and fields the spec does not have.
+const projectSpecSchema = `{
+  "type": "object",
+  "required": ["name", "type", "framework", "components", "files", "description"],
+  "additionalProperties": false,
+  "properties": {
+    "name": {"type": "string", "minLength": 1},
+    "type": {"type": "string", "minLength": 1},
+    "framework": {"type": "string", "minLength": 1},
+    "components": {"type": "array", "items": {"type": "string"}},
+    "files": {"type": "object", "minProperties": 1, "additionalProperties": {"type": "string"}},
+    "description": {"type": "string"},
+    "ui_files": {"type": "array", "items": {"type": "string"}},
+    "large_files": {"type": "array", "items": {"type": "string"}},
+    "snippets": {"type": "object", "additionalProperties": {"type": "string"}},
+    "order": {"type": "array", "items": {"type": "string"}},
+    "exclude_from_context": {"type": "array", "items": {"type": "string"}}
+  }
+}`
+
+// jsonSchema is the subset of JSON schema used by projectSpecSchema.
+type jsonSchema struct {
+	Type          string                 `json:"type"`
+	Required      []string               `json:"required"`
+	Properties    map[string]*jsonSchema `json:"properties"`
+	Items         *jsonSchema            `json:"items"`
+	MinLength     int                    `json:"minLength"`
+	MinProperties int                    `json:"minProperties"`
+	// AdditionalProperties is false, to reject properties not listed, or
+	// the schema of their values
+	AdditionalProperties json.RawMessage `json:"additionalProperties"`
+}
+
+// specSchema is projectSpecSchema parsed.
+var specSchema = mustParseSchema(projectSpecSchema)
+
+func mustParseSchema(data string) *jsonSchema {
+	var schema jsonSchema
+	if err := json.Unmarshal([]byte(data), &schema); err != nil {
+		panic(fmt.Sprintf("invalid JSON schema: %v", err))
+	}
+	return &schema
+}
+
+// schemaError lists the constraints of projectSpecSchema a planned spec
+// failed.
+type schemaError struct {
+	Problems []string
+}
+
+func (e *schemaError) Error() string {
+	return fmt.Sprintf("project spec does not match its schema:\n- %s", strings.Join(e.Problems, "\n- "))
+}
+
+// checkSpecSchema validates a spec in JSON against projectSpecSchema and
+// returns a schemaError naming each failed constraint.
+func checkSpecSchema(content string) error {
+	var value interface{}
+	if err := json.Unmarshal([]byte(content), &value); err != nil {
+		return err
+	}
+	if problems := specSchema.validate(value, "spec"); len(problems) > 0 {
+		return &schemaError{Problems: problems}
+	}
+	return nil
+}
+
+// jsonType returns the JSON schema type of a decoded JSON value.
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// validate returns the constraints of s that value, found at path, fails.
+func (s *jsonSchema) validate(value interface{}, path string) []string {
+	if actual := jsonType(value); s.Type != "" && actual != s.Type {
+		return []string{fmt.Sprintf("%s must be of type %s, not %s", path, s.Type, actual)}
+	}
+
+	var problems []string
+	switch value := value.(type) {
+	case string:
+		if len(strings.TrimSpace(value)) < s.MinLength {
+			problems = append(problems, fmt.Sprintf("%s must not be empty", path))
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range value {
+				problems = append(problems, s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := value[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s is missing the required field %q", path, name))
+			}
+		}
+		if len(value) < s.MinProperties {
+			problems = append(problems, fmt.Sprintf("%s must have at least %d entries", path, s.MinProperties))
+		}
+
+		var additional *jsonSchema
+		closed := string(s.AdditionalProperties) == "false"
+		if len(s.AdditionalProperties) > 0 && !closed {
+			additional = mustParseSchema(string(s.AdditionalProperties))
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			switch {
+			case ok:
+				problems = append(problems, property.validate(value[name], path+"."+name)...)
+			case closed:
+				problems = append(problems, fmt.Sprintf("%s has the unknown field %q", path, name))
+			case additional != nil:
+				problems = append(problems, additional.validate(value[name], fmt.Sprintf("%s[%q]", path, name))...)
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// specFormats are the ways -spec-format can display a spec.
+var specFormats = []string{"json", "tree"}
+
+// maxTreeDescription is the length at which file descriptions are cut in
+// the tree view.
+const maxTreeDescription = 80
+
+// formatSpec renders the spec for review, as indented JSON or as a tree of
+// its files grouped by directory.
+func formatSpec(spec *ProjectSpec, format string) string {
+	if format != "tree" {
+		specJSON, _ := json.MarshalIndent(spec, "", "  ")
+		return string(specJSON)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s, %s)\n", spec.Name, spec.Type, spec.Framework)
+	if spec.Description != "" {
+		fmt.Fprintf(&b, "%s\n", spec.Description)
+	}
+	if len(spec.Components) > 0 {
+		fmt.Fprintf(&b, "Components: %s\n", strings.Join(spec.Components, ", "))
+	}
+	fmt.Fprintf(&b, "\n%s/\n", spec.Name)
+
+	root := &specTreeNode{}
+	for filePath := range spec.Files {
+		root.add(strings.Split(filePath, "/"), filePath)
+	}
+	root.write(&b, spec, "")
+	fmt.Fprintf(&b, "\n%d files", len(spec.Files))
+	return b.String()
+}
+
+// specTreeNode is a directory of the tree view, or a file if path is set.
+type specTreeNode struct {
+	path     string
+	children map[string]*specTreeNode
+}
+
+func (n *specTreeNode) add(parts []string, filePath string) {
+	if n.children == nil {
+		n.children = make(map[string]*specTreeNode)
+	}
+	child := n.children[parts[0]]
+	if child == nil {
+		child = &specTreeNode{}
+		n.children[parts[0]] = child
+	}
+	if len(parts) == 1 {
+		child.path = filePath
+		return
+	}
+	child.add(parts[1:], filePath)
+}
+
+// write prints the node's children, directories before files and each
+// sorted by name.
+func (n *specTreeNode) write(b *strings.Builder, spec *ProjectSpec, indent string) {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		iDir, jDir := n.children[names[i]].children != nil, n.children[names[j]].children != nil
+		if iDir != jDir {
+			return iDir
+		}
+		return names[i] < names[j]
+	})
+
+	for i, name := range names {
+		child := n.children[name]
+		branch, nextIndent := "├── ", indent+"│   "
+		if i == len(names)-1 {
+			branch, nextIndent = "└── ", indent+"    "
+		}
+		if child.children != nil {
+			fmt.Fprintf(b, "%s%s%s/\n", indent, branch, name)
+			child.write(b, spec, nextIndent)
+			continue
+		}
+		fmt.Fprintf(b, "%s%s%s", indent, branch, name)
+		if description := shortDescription(spec.Files[child.path]); description != "" {
+			fmt.Fprintf(b, " — %s", description)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// shortDescription returns the first line of a file description, cut to
+// maxTreeDescription characters.
+func shortDescription(description string) string {
+	description = strings.TrimSpace(description)
+	if i := strings.IndexByte(description, '\n'); i >= 0 {
+		description = strings.TrimSpace(description[:i])
+	}
+	if utf8.RuneCountInString(description) <= maxTreeDescription {
+		return description
+	}
+	runes := []rune(description)
+	return strings.TrimSpace(string(runes[:maxTreeDescription-1])) + "…"
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Levels of log records sent to a logSink.
+const (
+	levelDebug = "debug"
+	levelInfo  = "info"
+	levelWarn  = "warn"
+	levelError = "error"
+)
+
+// logOutputs describes the targets accepted by -log-output.
+const logOutputs = "json:<file>, syslog or journald"
+
+// logSink receives log records instead of the console, e.g. when running
+// as a service.
+type logSink interface {
+	Log(level, message string) error
+}
+
+// openLogSink opens the sink for a -log-output target.
+func openLogSink(target string) (logSink, error) {
+	if path, ok := strings.CutPrefix(target, "json:"); ok {
+		if path == "" {
+			return nil, fmt.Errorf("missing file in log output %q (use json:<file>)", target)
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, describeFSError("open log file", path, err)
+		}
+		return &jsonSink{file: file}, nil
+	}
+	switch target {
+	case "syslog":
+		return newSyslogSink(logIdentifier())
+	case "journald":
+		return newJournaldSink(logIdentifier())
+	}
+	return nil, fmt.Errorf("unknown log output %q (use %s)", target, logOutputs)
+}
+
+// logIdentifier names the program in syslog and journald records.
+func logIdentifier() string {
+	return filepath.Base(os.Args[0])
+}
+
+// statusMessage strips the emoji that prefix console status messages, as
+// structured records carry the level separately.
+func statusMessage(message string) string {
+	return strings.TrimLeftFunc(message, func(r rune) bool {
+		return isEmoji(r) || r == 0xFE0F || r == 0x200D || r == ' '
+	})
+}
+
+// jsonSink appends one JSON object per record to a file.
+type jsonSink struct {
+	file *os.File
+}
+
+func (s *jsonSink) Log(level, message string) error {
+	data, err := json.Marshal(struct {
+		Time    string `json:"time"`
+		Level   string `json:"level"`
+		Message string `json:"message"`
+	}{time.Now().UTC().Format(time.RFC3339Nano), level, message})
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(data, '\n'))
+	return err
+}
+
+// journaldSocket is where journald accepts records in its native protocol.
+const journaldSocket = "/run/systemd/journal/socket"
+
+// journaldPriorities maps levels to syslog priorities.
+var journaldPriorities = map[string]string{
+	levelDebug: "7",
+	levelInfo:  "6",
+	levelWarn:  "4",
+	levelError: "3",
+}
+
+// journaldSink sends records to journald over its native protocol.
+type journaldSink struct {
+	conn       net.Conn
+	identifier string
+}
+
+func newJournaldSink(identifier string) (logSink, error) {
+	conn, err := net.Dial("unixgram", journaldSocket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %v", err)
+	}
+	return &journaldSink{conn: conn, identifier: identifier}, nil
+}
+
+func (s *journaldSink) Log(level, message string) error {
+	var b []byte
+	for _, field := range [][2]string{
+		{"PRIORITY", journaldPriorities[level]},
+		{"SYSLOG_IDENTIFIER", s.identifier},
+		{"MESSAGE", message},
+	} {
+		// Values with newlines are sent with their length instead of "="
+		if strings.Contains(field[1], "\n") {
+			b = append(b, field[0]+"\n"...)
+			b = binary.LittleEndian.AppendUint64(b, uint64(len(field[1])))
+			b = append(b, field[1]+"\n"...)
+		} else {
+			b = append(b, field[0]+"="+field[1]+"\n"...)
+		}
+	}
+	_, err := s.conn.Write(b)
+	return err
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// stubContent returns the stub of filePath: its description as a TODO
+// comment, or nothing for files that cannot hold comments, such as JSON.
+func stubContent(filePath, description string) string {
+	comment, ok := formatHeader(filePath, "TODO: "+description)
+	if !ok {
+		return ""
+	}
+	return comment
+}
+
+// WriteStubs lays out the project without calling the API: it creates the
+// project directory with a stub for each file of the spec, so the layout
+// can be inspected and tooling wired up before generating. Files that
+// already exist are kept. It returns the project directory.
+func (a *DevAgent) WriteStubs(spec *ProjectSpec) (string, error) {
+	spec = a.named(withoutDependsHints(spec))
+	projectDir := a.ProjectDir(spec)
+	proceed, err := a.prepareProjectDir(spec, projectDir)
+	if err != nil || !proceed {
+		return "", err
+	}
+
+	written := 0
+	for _, filePath := range generationOrder(spec) {
+		if _, err := os.Stat(filepath.Join(projectDir, filePath)); err == nil {
+			a.Log.Infof("⏭️  Keeping existing %s", filePath)
+			continue
+		}
+		if err := writeProjectFile(projectDir, filePath, stubContent(filePath, spec.Files[filePath])); err != nil {
+			return projectDir, err
+		}
+		written++
+	}
+	a.Log.Infof("📐 Wrote %d stub file(s) to %s", written, projectDir)
+	return projectDir, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// stringList is a flag that can be repeated, collecting every value.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// loadStyleExamples reads the exemplar files, keyed by file name, or by
+// path when two share a name.
+func loadStyleExamples(paths []string) (map[string]string, error) {
+	examples := make(map[string]string, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, describeFSError("read style example", path, err)
+		}
+		name := filepath.Base(path)
+		if _, taken := examples[name]; taken {
+			name = filepath.ToSlash(path)
+		}
+		examples[name] = string(data)
+	}
+	return examples, nil
+}
+
+// styleGuideRatio is the share of the code model's context window a style
+// guide may take; longer guides are truncated.
+const styleGuideRatio = 0.1
+
+// loadStyleGuide reads a style guide and truncates it at a line boundary
+// to styleGuideRatio of the context window of model. It reports whether
+// the guide was truncated.
+func loadStyleGuide(path, model string) (string, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, describeFSError("read style guide", path, err)
+	}
+	guide := strings.TrimSpace(string(data))
+	limit := int(float64(contextLimit(model)) * styleGuideRatio)
+	if estimateTokens(model, guide) <= limit {
+		return guide, false, nil
+	}
+	cut := truncateTokens(model, guide, limit)
+	if i := strings.LastIndexByte(cut, '\n'); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut), true, nil
+}
+
+// styleGuideInstruction returns the system prompt section holding the
+// style guide, or an empty string without one.
+func (a *DevAgent) styleGuideInstruction() string {
+	if a.StyleGuide == "" {
+		return ""
+	}
+	return "\n\nFollow this style guide in all code you write:\n" + a.StyleGuide
+}
+
+// styleInstruction returns the system prompt section showing the style
+// examples for filePath: those in the file's language if there are any,
+// otherwise all of them. It is empty without examples.
+func (a *DevAgent) styleInstruction(filePath string) string {
+	if len(a.StyleExamples) == 0 {
+		return ""
+	}
+	language := sourceLanguage(filePath)
+	var names, sameLanguage []string
+	for name := range a.StyleExamples {
+		names = append(names, name)
+		if language != "" && sourceLanguage(name) == language {
+			sameLanguage = append(sameLanguage, name)
+		}
+	}
+	if len(sameLanguage) > 0 {
+		names = sameLanguage
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("\n\nWrite the code in the style of the following examples: naming, formatting, comments, error handling and structure. They are style references only; never copy their content into the file.\n")
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("\nExample %s:\n```\n%s\n```\n", name, strings.TrimSpace(a.StyleExamples[name])))
+	}
+	return b.String()
+}
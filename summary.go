@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// renderSummary describes a generated project in markdown: what was
+// generated, where it was written and what it cost.
+func (a *DevAgent) renderSummary(spec *ProjectSpec, projectDir string, manifest *Manifest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", spec.Name)
+	if spec.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", spec.Description)
+	}
+
+	location := projectDir
+	if abs, err := filepath.Abs(projectDir); err == nil {
+		location = abs
+	}
+	fmt.Fprintf(&b, "- **Type:** %s\n", spec.Type)
+	fmt.Fprintf(&b, "- **Framework:** %s\n", spec.Framework)
+	fmt.Fprintf(&b, "- **Location:** `%s`\n", location)
+	fmt.Fprintf(&b, "- **Generated:** %s\n", time.Now().Format(time.RFC1123))
+
+	if len(spec.Components) > 0 {
+		b.WriteString("\n## Components\n\n")
+		for _, component := range spec.Components {
+			fmt.Fprintf(&b, "- %s\n", component)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## Files (%d)\n\n", len(manifest.Files))
+	b.WriteString("| Path | Kind | Description |\n|------|------|-------------|\n")
+	for _, entry := range manifest.Files {
+		description := strings.Join(strings.Fields(spec.Files[entry.Path]), " ")
+		if entry.TestFor != "" {
+			description = "Tests for " + entry.TestFor
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s |\n", entry.Path, entry.Kind, strings.ReplaceAll(description, "|", `\|`))
+	}
+
+	b.WriteString("\n## Usage\n\n")
+	fmt.Fprintf(&b, "- **Tokens:** %s\n", a.Usage.Summary())
+	if cost, known := a.Usage.Cost(); known {
+		fmt.Fprintf(&b, "- **Cost:** ~$%.2f\n", cost)
+	} else if cost > 0 {
+		fmt.Fprintf(&b, "- **Cost:** more than ~$%.2f (some model prices are not listed)\n", cost)
+	}
+	return b.String()
+}
+
+// writeSummary writes the markdown summary of the project to SummaryFile.
+// The summary is informational, so failing to write it is only reported.
+func (a *DevAgent) writeSummary(spec *ProjectSpec, projectDir string, manifest *Manifest) {
+	if a.SummaryFile == "" {
+		return
+	}
+	if dir := filepath.Dir(a.SummaryFile); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			a.Log.Warnf("%v", describeFSError("create directory", dir, err))
+			return
+		}
+	}
+	err := os.WriteFile(a.SummaryFile, []byte(a.renderSummary(spec, projectDir, manifest)), 0644)
+	if err != nil {
+		a.Log.Warnf("%v", describeFSError("write summary file", a.SummaryFile, err))
+		return
+	}
+	a.Log.Infof("📝 Summary written to %s", a.SummaryFile)
+}
@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package main
+
+import "errors"
+
+// newSyslogSink fails on systems without syslog.
+func newSyslogSink(identifier string) (logSink, error) {
+	return nil, errors.New("syslog is not available on this system")
+}
@@ -0,0 +1,33 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// syslogSink sends records to the local syslog daemon.
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+func newSyslogSink(identifier string) (logSink, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_USER, identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %v", err)
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) Log(level, message string) error {
+	switch level {
+	case levelDebug:
+		return s.w.Debug(message)
+	case levelWarn:
+		return s.w.Warning(message)
+	case levelError:
+		return s.w.Err(message)
+	}
+	return s.w.Info(message)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// defaultSpecTemplate renders the system prompt that asks the model to plan
+// a project as a JSON spec.
+const defaultSpecTemplate = `As an AI development agent, analyze the user's request and create a detailed project specification.
+Think through this step by step:
+
+1. Understand the core requirements
+2. Identify the best framework and technologies
+3. Break down the components needed
+4. Plan the file structure
+5. Create a comprehensive project specification
+
+Generate a JSON project specification that includes:
+- Project name
+- Project type (web, mobile, cli, etc.)
+- Framework recommendation
+- List of required components
+- File structure (provide all the files and their descriptions required for production ready code)
+- Project description
+{{.ProfileInstruction}}
+Respond only with valid JSON in the following structure:
+{
+  "name": "<project name>",
+  "type": "<project type>",
+  "framework": "<recommended framework>",
+  "components": [
+    "<component 1>",
+    "<component 2>",
+    ...
+  ],
+  "files": {
+    "<file 1 path>": "<file 1 description and prompt to generate file and import chains>",
+    "<file 2 path>": "<file 2 description and prompt to generate file and import chains>",
+    ...
+  },
+  "description": "<project description>",
+  "ui_files": [
+    "<path of each file that renders or styles the user interface, if any>"
+  ],
+  "large_files": [
+    "<path of each file expected to exceed 500 lines, if any>"
+  ],
+  "snippets": {
+    "<snippet name>": "<boilerplate repeated verbatim in several files, such as a license header, if any>"
+  },
+  "order": [
+    "<path of each file that must be generated before the others, in sequence, if any>"
+  ],
+  "exclude_from_context": [
+    "<path of each file, such as large generated data, that other files do not need to see, if any>"
+  ]
+}`
+
+// defaultCodeTemplate renders the prompt that generates one file of a spec.
+const defaultCodeTemplate = `Generate the complete code for the file {{.FilePath}} in the {{.Spec.Name}} project.
+Project Description: {{.Spec.Description}}
+File Purpose: {{.Description}}
+
+Requirements:
+- Use {{.Spec.Framework}} framework
+- Follow best practices
+- Include necessary imports
+- Add helpful comments
+- Make sure the code is complete and functional
+- Ensure compatibility with other project files
+{{.ProfileInstruction}}{{.ImportInstruction}}{{.ThemeInstruction}}{{.SnippetInstruction}}{{.Context}}
+Generate only the code, no explanations.`
+
+// specPromptData is available to spec templates.
+type specPromptData struct {
+	// Prompt is the user's project description
+	Prompt string
+	// ProfileInstruction holds the requirement lines of the -profile
+	ProfileInstruction string
+}
+
+// codePromptData is available to code templates.
+type codePromptData struct {
+	Spec        *ProjectSpec
+	FilePath    string
+	Description string
+
+	// Requirement lines derived from the agent's options, each ending in
+	// a newline or empty
+	ProfileInstruction string
+	ImportInstruction  string
+	ThemeInstruction   string
+	SnippetInstruction string
+
+	// Context is the rendered section of previously generated files, and
+	// Files holds the same files by path
+	Context string
+	Files   map[string]string
+}
+
+// sampleSpec is used to check templates when they are loaded.
+var sampleSpec = &ProjectSpec{
+	Name:        "sample",
+	Type:        "web",
+	Framework:   "Go",
+	Components:  []string{"server"},
+	Files:       map[string]string{"main.go": "Entry point"},
+	Description: "A sample project",
+}
+
+// parsePromptTemplate parses a prompt template and renders it once with
+// sample data, so that unknown fields are reported at load time rather
+// than during generation.
+func parsePromptTemplate(name, text string, sample interface{}) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %v", name, err)
+	}
+	if _, err := renderPrompt(tmpl, sample); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// loadPromptTemplate reads a template file, expanding @include directives,
+// and validates it against sample data.
+func loadPromptTemplate(path, name string, sample interface{}) (*template.Template, error) {
+	text, err := loadPromptFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parsePromptTemplate(name, text, sample)
+}
+
+// renderPrompt executes a prompt template.
+func renderPrompt(tmpl *template.Template, data interface{}) (string, error) {
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %v", tmpl.Name(), err)
+	}
+	return out.String(), nil
+}
+
+// Sample data that prompt templates are validated with
+var (
+	sampleSpecData = specPromptData{Prompt: "A sample project"}
+	sampleCodeData = codePromptData{
+		Spec:        sampleSpec,
+		FilePath:    "main.go",
+		Description: "Entry point",
+		Files:       map[string]string{},
+	}
+)
+
+// Templates the agent starts with
+var (
+	defaultSpecTmpl = template.Must(parsePromptTemplate("spec", defaultSpecTemplate, sampleSpecData))
+	defaultCodeTmpl = template.Must(parsePromptTemplate("code", defaultCodeTemplate, sampleCodeData))
+)
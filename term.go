@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// isInteractive reports whether stdin is a terminal, i.e. whether the user
+// can answer prompts.
+func isInteractive() bool {
+	return isTerminal(os.Stdin)
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// unicodeTerminal reports whether emoji can be printed: false only when
+// the first of LC_ALL, LC_CTYPE and LANG that is set names a locale other
+// than UTF-8. With none set, as in many containers and CI runners, and on
+// Windows, Unicode is assumed.
+func unicodeTerminal() bool {
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := strings.ToLower(os.Getenv(name)); locale != "" {
+			return strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8")
+		}
+	}
+	return true
+}
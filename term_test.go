@@ -0,0 +1,34 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestUnicodeTerminal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows consoles always handle Unicode")
+	}
+	tests := []struct {
+		name                 string
+		lcAll, lcCtype, lang string
+		want                 bool
+	}{
+		{"no locale", "", "", "", true},
+		{"utf-8 lang", "", "", "en_US.UTF-8", true},
+		{"utf8 lang", "", "", "C.utf8", true},
+		{"c locale", "", "", "C", false},
+		{"posix lc_all", "POSIX", "", "en_US.UTF-8", false},
+		{"latin-1 lc_ctype", "", "de_DE.ISO-8859-1", "en_US.UTF-8", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LC_ALL", tt.lcAll)
+			t.Setenv("LC_CTYPE", tt.lcCtype)
+			t.Setenv("LANG", tt.lang)
+			if got := unicodeTerminal(); got != tt.want {
+				t.Errorf("unicodeTerminal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
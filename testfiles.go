@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// testFilePath returns where the test for the given source file belongs
+// according to its language's conventions. It reports false for files
+// that are not testable source code or are tests themselves.
+func testFilePath(filePath string) (string, bool) {
+	dir, base := filepath.Split(filePath)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+
+	switch strings.ToLower(ext) {
+	case ".go":
+		if strings.HasSuffix(name, "_test") {
+			return "", false
+		}
+		return dir + name + "_test.go", true
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		if strings.HasSuffix(name, ".test") || strings.HasSuffix(name, ".spec") || strings.HasSuffix(name, ".config") || strings.HasSuffix(name, ".d") {
+			return "", false
+		}
+		return dir + name + ".test" + ext, true
+	case ".py":
+		if strings.HasPrefix(name, "test_") || strings.HasSuffix(name, "_test") || name == "__init__" || name == "conftest" || name == "setup" {
+			return "", false
+		}
+		return dir + "test_" + name + ".py", true
+	case ".java", ".kt":
+		if strings.HasSuffix(name, "Test") || name == "" || !unicode.IsUpper(rune(name[0])) {
+			return "", false
+		}
+		slashDir := filepath.ToSlash(dir)
+		if strings.Contains(slashDir, "src/main/") {
+			slashDir = strings.Replace(slashDir, "src/main/", "src/test/", 1)
+		}
+		return filepath.FromSlash(slashDir) + name + "Test" + ext, true
+	case ".rb":
+		if strings.HasSuffix(name, "_spec") {
+			return "", false
+		}
+		return filepath.Join("spec", strings.TrimPrefix(filepath.ToSlash(dir), "lib/"), name+"_spec.rb"), true
+	}
+	return "", false
+}
+
+// GenerateTestFile generates the test file at testPath for the already
+// generated source file, using the source and the rest of the project as
+// context.
+func (a *DevAgent) GenerateTestFile(spec *ProjectSpec, sourcePath, testPath string, context map[string]string) (string, error) {
+	testPrompt := fmt.Sprintf(`Generate the complete test file %s for the source file %s in the %s project.
+Project Description: %s
+Source File Purpose: %s
+
+Source file %s:
+`+"```"+`
+%s
+`+"```"+`
+
+Requirements:
+- Use the idiomatic testing framework for the language and the %s framework
+- Cover the main behavior and important edge cases
+- Only test what the source file actually exports or defines
+- Include necessary imports
+- Make sure the tests compile and run without external services
+%s
+Generate only the code, no explanations.`, testPath, sourcePath, spec.Name, spec.Description, spec.Files[sourcePath], sourcePath, context[sourcePath], spec.Framework, a.importInstruction(spec))
+
+	content, err := a.chat(chatRequest{
+		Label:       testPath,
+		Model:       a.codeModelFor(testPath),
+		System:      a.codeSystemPromptFor(testPath),
+		Prompt:      a.wrapCodePrompt(testPrompt),
+		Temperature: 0.2,
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("failed to generate test for %s: %v", sourcePath, err)
+	}
+
+	return stripCodeFences(testPath, content), nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// existingSourceDescription describes the files of a project that was not
+// planned by a spec.
+const existingSourceDescription = "Existing source file of the project"
+
+// GenerateTestsOnly generates a test file for each source file of an
+// existing project that has none yet, named after the conventions of its
+// language, leaving all other files untouched. Each test is generated with
+// only its source file as context. spec supplies the project's name,
+// description and framework; when it is nil the name is the directory's
+// and the framework its languages.
+func (a *DevAgent) GenerateTestsOnly(projectDir string, spec *ProjectSpec) error {
+	files, err := loadContextDir(projectDir)
+	if err != nil {
+		return err
+	}
+
+	var sources []string
+	tests := make(map[string]string)
+	for filePath := range files {
+		testPath, ok := testFilePath(filePath)
+		if !ok {
+			continue
+		}
+		testPath = filepath.ToSlash(testPath)
+		if _, err := os.Stat(filepath.Join(projectDir, testPath)); err == nil {
+			a.Log.Debugf("Skipping %s: %s already exists", filePath, testPath)
+			continue
+		}
+		sources = append(sources, filePath)
+		tests[filePath] = testPath
+	}
+	sort.Strings(sources)
+	if len(sources) == 0 {
+		a.Log.Infof("⏭️  No source files without tests found in %s", projectDir)
+		return nil
+	}
+
+	if spec == nil {
+		spec = &ProjectSpec{Name: filepath.Base(filepath.Clean(projectDir))}
+		spec.Framework = strings.Join(groupByLanguage(append([]string(nil), sources...)), ", ")
+	}
+	// GenerateTestFile describes the source with its entry in Files
+	described := *spec
+	described.Files = make(map[string]string, len(sources))
+	for _, filePath := range sources {
+		described.Files[filePath] = existingSourceDescription
+		if description, ok := spec.Files[filePath]; ok {
+			described.Files[filePath] = description
+		}
+	}
+
+	a.Log.Infof("🧪 Generating tests for %d source file(s) in %s", len(sources), projectDir)
+	var written []string
+	for _, sourcePath := range sources {
+		testPath := tests[sourcePath]
+		done := a.startFile(testPath)
+		content, err := a.GenerateTestFile(&described, sourcePath, testPath, map[string]string{sourcePath: files[sourcePath]})
+		if err == nil && strings.TrimSpace(content) == "" {
+			err = fmt.Errorf("%s was generated empty", testPath)
+		}
+		if err == nil {
+			err = writeProjectFile(projectDir, testPath, a.finalizeContent(testPath, content))
+		}
+		if err != nil && a.KeepGoing {
+			a.fileFailed(testPath, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		done()
+		written = append(written, testPath)
+	}
+
+	if len(written) < len(sources) {
+		var missing []string
+		for _, sourcePath := range sources {
+			if indexOf(written, tests[sourcePath]) < 0 {
+				missing = append(missing, tests[sourcePath])
+			}
+		}
+		return &PartialError{Files: missing}
+	}
+	a.Log.Infof("✨ Generated %d test file(s)!", len(written))
+	return nil
+}
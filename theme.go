@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// uiExtensions are used to recognize user interface files when the spec
+// does not list them explicitly.
+var uiExtensions = map[string]bool{
+	".html":   true,
+	".css":    true,
+	".scss":   true,
+	".sass":   true,
+	".less":   true,
+	".jsx":    true,
+	".tsx":    true,
+	".vue":    true,
+	".svelte": true,
+}
+
+// isUIFile reports whether the file renders or styles the user interface.
+// Files listed in the spec's ui_files take precedence over the extension
+// heuristic.
+func isUIFile(spec *ProjectSpec, filePath string) bool {
+	if len(spec.UIFiles) > 0 {
+		for _, uiFile := range spec.UIFiles {
+			if uiFile == filePath {
+				return true
+			}
+		}
+		return false
+	}
+	return uiExtensions[strings.ToLower(filepath.Ext(filePath))]
+}
+
+// themeInstruction returns a prompt requirement line for the design theme
+// of UI files, or an empty string if no theme applies to the file.
+func (a *DevAgent) themeInstruction(spec *ProjectSpec, filePath string) string {
+	if a.Theme == "" || !isUIFile(spec, filePath) {
+		return ""
+	}
+	return fmt.Sprintf("- Style the user interface with a %s design theme\n", a.Theme)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+func init() {
+	// The encodings are embedded instead of downloaded on first use
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+}
+
+// modelContextLimits holds the context window, in tokens, of known models.
+// Dated snapshots (e.g. gpt-4o-2024-08-06) match by their longest prefix.
+var modelContextLimits = map[string]int{
+	"gpt-3.5-turbo": 16385,
+	"gpt-4":         8192,
+	"gpt-4-32k":     32768,
+	"gpt-4-turbo":   128000,
+	"gpt-4o":        128000,
+	"gpt-4o-mini":   128000,
+	"gpt-4.1":       1047576,
+	"o1":            200000,
+	"o1-mini":       128000,
+	"o3":            200000,
+	"o3-mini":       200000,
+	"o4-mini":       200000,
+}
+
+// defaultContextLimit is assumed for models missing from the table.
+const defaultContextLimit = 8192
+
+// contextWarnRatio is the share of the context window above which a prompt
+// triggers a warning.
+const contextWarnRatio = 0.8
+
+// contextLimit returns the context window of the given model.
+func contextLimit(model string) int {
+	best := ""
+	for name := range modelContextLimits {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return defaultContextLimit
+	}
+	return modelContextLimits[best]
+}
+
+// defaultEncoding tokenizes models tiktoken does not know, such as other
+// providers' models, the o-series or vendor-prefixed names.
+const defaultEncoding = tiktoken.MODEL_O200K_BASE
+
+// Tokenizers are expensive to build, so one per encoding is kept.
+var (
+	encodingsMu sync.Mutex
+	encodings   = map[string]*tiktoken.Tiktoken{}
+)
+
+// encodingFor returns the tokenizer of model, or nil if none could be
+// loaded.
+func encodingFor(model string) *tiktoken.Tiktoken {
+	name := defaultEncoding
+	if _, bare, ok := strings.Cut(model, "/"); ok {
+		model = bare
+	}
+	if encoding, ok := tiktoken.MODEL_TO_ENCODING[model]; ok {
+		name = encoding
+	} else {
+		// The longest matching prefix wins, whatever the map order
+		best := ""
+		for prefix, encoding := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+			if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+				best, name = prefix, encoding
+			}
+		}
+	}
+
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+	if encoding, ok := encodings[name]; ok {
+		return encoding
+	}
+	encoding, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		encoding = nil
+	}
+	encodings[name] = encoding
+	return encoding
+}
+
+// estimateTokens counts the tokens of text with the tokenizer of model.
+// Should the tokenizer fail to load, it falls back to about four
+// characters per token.
+func estimateTokens(model, text string) int {
+	if encoding := encodingFor(model); encoding != nil {
+		return len(encoding.EncodeOrdinary(text))
+	}
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// truncateTokens returns the longest prefix of text that is at most limit
+// tokens for model.
+func truncateTokens(model, text string, limit int) string {
+	encoding := encodingFor(model)
+	if encoding == nil {
+		if runes := []rune(text); len(runes) > limit*4 {
+			return string(runes[:limit*4])
+		}
+		return text
+	}
+	tokens := encoding.EncodeOrdinary(text)
+	if len(tokens) <= limit {
+		return text
+	}
+	// A token cut in the middle of a character decodes to an invalid rune
+	return strings.TrimRight(encoding.Decode(tokens[:limit]), string(utf8.RuneError))
+}
+
+// estimateChatTokens counts the prompt size of a chat request to model,
+// including the per-message formatting overhead.
+func estimateChatTokens(model string, messages ...string) int {
+	tokens := 3
+	for _, m := range messages {
+		tokens += 4 + estimateTokens(model, m)
+	}
+	return tokens
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestEncodingFor(t *testing.T) {
+	tests := []struct {
+		model, sameAs string
+	}{
+		{"gpt-4o-2024-08-06", "gpt-4o"},
+		{"gpt-4-0613", "gpt-4"},
+		{"gpt-3.5-turbo-0125", "gpt-3.5-turbo"},
+		{"openai/gpt-4-0613", "gpt-4"},
+		{"claude-3-5-sonnet", "o1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			want := encodingFor(tt.sameAs)
+			if want == nil {
+				t.Fatalf("no encoding for %s", tt.sameAs)
+			}
+			// Repeat, as map order once made the result vary between calls
+			for i := 0; i < 20; i++ {
+				if got := encodingFor(tt.model); got != want {
+					t.Fatalf("encodingFor(%s) differs from the encoding of %s", tt.model, tt.sameAs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxSpecContinuations is how often the rest of a project spec cut off at
+// the model's output limit is requested.
+const maxSpecContinuations = 3
+
+// continueSpec completes a project spec reply that stopped at the model's
+// output limit by asking for the rest of the JSON, until the model finishes
+// or maxSpecContinuations is reached. It returns the completed JSON and the
+// number of continuations requested.
+func (a *DevAgent) continueSpec(partial string) (string, int, error) {
+	for n := 1; n <= maxSpecContinuations; n++ {
+		a.Log.Warnf("The project spec was cut off at the model's output limit, requesting the rest (%d/%d)", n, maxSpecContinuations)
+
+		continuePrompt := fmt.Sprintf(`The following JSON document was cut off in the middle:
+`+"```"+`
+%s
+`+"```"+`
+Continue it exactly where it stops so that appending your reply completes a valid JSON document.
+Do not repeat any of the text above and do not start a new document.`, partial)
+
+		reply, err := a.chatReply(chatRequest{
+			Label:       "project spec",
+			Step:        "continuation",
+			Model:       a.SpecModel,
+			System:      "You complete truncated JSON documents. Reply only with the missing remainder, no explanations or markdown.",
+			Prompt:      continuePrompt,
+			Temperature: 0,
+		})
+		if err != nil {
+			return "", n, fmt.Errorf("failed to complete truncated project spec: %v", err)
+		}
+		// Whitespace may belong to a string value, so only a fenced reply
+		// is trimmed
+		rest := reply.Content
+		if strings.HasPrefix(strings.TrimSpace(rest), "```") {
+			rest = stripCodeFences("", rest)
+		}
+		partial += rest
+		if !reply.truncated() {
+			return partial, n, nil
+		}
+	}
+	return partial, maxSpecContinuations, nil
+}
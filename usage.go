@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Usage accumulates the tokens consumed by API calls. Tokens spent on
+// attempts that failed and were retried are tracked separately, since they
+// cost money without producing output.
+type Usage struct {
+	mu sync.Mutex
+
+	Calls            int
+	PromptTokens     int
+	CompletionTokens int
+
+	FailedAttempts        int
+	RetryPromptTokens     int
+	RetryCompletionTokens int
+	// RetryEstimated is set when failed attempts did not report usage and
+	// their prompt size had to be estimated
+	RetryEstimated bool
+
+	// byLabel holds the tokens consumed per request label, e.g. per file
+	byLabel map[string]int
+	// byModel holds the prompt and completion tokens consumed per model,
+	// including failed attempts, for pricing
+	byModel map[string]modelTokens
+}
+
+// modelTokens counts the tokens consumed with one model.
+type modelTokens struct {
+	Prompt     int
+	Completion int
+}
+
+// add attributes tokens to label; the caller holds the lock.
+func (u *Usage) add(label string, tokens int) {
+	if u.byLabel == nil {
+		u.byLabel = make(map[string]int)
+	}
+	u.byLabel[label] += tokens
+}
+
+// addModel attributes tokens to model; the caller holds the lock.
+func (u *Usage) addModel(model string, prompt, completion int) {
+	if u.byModel == nil {
+		u.byModel = make(map[string]modelTokens)
+	}
+	tokens := u.byModel[model]
+	tokens.Prompt += prompt
+	tokens.Completion += completion
+	u.byModel[model] = tokens
+}
+
+// TokensFor returns all tokens consumed by requests for label, including
+// failed attempts.
+func (u *Usage) TokensFor(label string) int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.byLabel[label]
+}
+
+// Record adds the usage of a successful call to model for label.
+func (u *Usage) Record(label, model string, usage openai.Usage) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.add(label, usage.PromptTokens+usage.CompletionTokens)
+	u.addModel(model, usage.PromptTokens, usage.CompletionTokens)
+	u.Calls++
+	u.PromptTokens += usage.PromptTokens
+	u.CompletionTokens += usage.CompletionTokens
+}
+
+// RecordFailure adds the usage of a failed attempt to model for label.
+// When the API reported no usage, estimatedPrompt is counted instead.
+func (u *Usage) RecordFailure(label, model string, usage openai.Usage, estimatedPrompt int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.FailedAttempts++
+	if usage.TotalTokens == 0 && estimatedPrompt > 0 {
+		u.add(label, estimatedPrompt)
+		u.addModel(model, estimatedPrompt, 0)
+		u.RetryPromptTokens += estimatedPrompt
+		u.RetryEstimated = true
+		return
+	}
+	u.add(label, usage.PromptTokens+usage.CompletionTokens)
+	u.addModel(model, usage.PromptTokens, usage.CompletionTokens)
+	u.RetryPromptTokens += usage.PromptTokens
+	u.RetryCompletionTokens += usage.CompletionTokens
+}
+
+// Snapshot returns a copy of the current counters.
+func (u *Usage) Snapshot() *Usage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	snapshot := &Usage{
+		Calls:                 u.Calls,
+		PromptTokens:          u.PromptTokens,
+		CompletionTokens:      u.CompletionTokens,
+		FailedAttempts:        u.FailedAttempts,
+		RetryPromptTokens:     u.RetryPromptTokens,
+		RetryCompletionTokens: u.RetryCompletionTokens,
+		RetryEstimated:        u.RetryEstimated,
+	}
+	for label, tokens := range u.byLabel {
+		snapshot.add(label, tokens)
+	}
+	for model, tokens := range u.byModel {
+		snapshot.addModel(model, tokens.Prompt, tokens.Completion)
+	}
+	return snapshot
+}
+
+// Reset clears all counters.
+func (u *Usage) Reset() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.Calls, u.PromptTokens, u.CompletionTokens = 0, 0, 0
+	u.FailedAttempts, u.RetryPromptTokens, u.RetryCompletionTokens = 0, 0, 0
+	u.RetryEstimated = false
+	u.byLabel = nil
+	u.byModel = nil
+}
+
+// Cost returns the price in USD of all tokens consumed, including failed
+// attempts. known is false when a model's price is unknown and the cost
+// is partial.
+func (u *Usage) Cost() (cost float64, known bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	known = true
+	for model, tokens := range u.byModel {
+		price, ok := priceFor(model)
+		if !ok {
+			known = false
+			continue
+		}
+		cost += price.cost(tokens.Prompt, tokens.Completion)
+	}
+	return cost, known
+}
+
+// TotalTokens returns all tokens consumed, including failed attempts.
+func (u *Usage) TotalTokens() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.PromptTokens + u.CompletionTokens + u.RetryPromptTokens + u.RetryCompletionTokens
+}
+
+// Summary describes the usage in one line, reporting retry overhead apart
+// from the tokens of successful calls.
+func (u *Usage) Summary() string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	summary := fmt.Sprintf("%d calls, %d prompt + %d completion tokens", u.Calls, u.PromptTokens, u.CompletionTokens)
+	if u.FailedAttempts > 0 {
+		approx := ""
+		if u.RetryEstimated {
+			approx = "~"
+		}
+		summary += fmt.Sprintf("; retry overhead: %d failed attempts, %s%d prompt + %d completion tokens", u.FailedAttempts, approx, u.RetryPromptTokens, u.RetryCompletionTokens)
+	}
+	return summary
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// validateSpec checks that a spec has everything generation relies on and
+// returns a single error listing all problems found.
+func validateSpec(spec *ProjectSpec) error {
+	var problems []string
+	switch name := spec.Name; {
+	case strings.TrimSpace(name) == "":
+		problems = append(problems, "name is empty")
+	case name == "." || !filepath.IsLocal(name) || strings.ContainsAny(name, `/\`):
+		problems = append(problems, fmt.Sprintf("name %q is not a single directory name", name))
+	}
+	if strings.TrimSpace(spec.Type) == "" {
+		problems = append(problems, "type is empty")
+	}
+	if strings.TrimSpace(spec.Framework) == "" {
+		problems = append(problems, "framework is empty")
+	}
+	if len(spec.Files) == 0 {
+		problems = append(problems, "no files are listed")
+	}
+
+	var filePaths []string
+	for filePath := range spec.Files {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+	for _, filePath := range filePaths {
+		switch {
+		case strings.TrimSpace(filePath) == "":
+			problems = append(problems, "a file has an empty path")
+		case filepath.IsAbs(filePath) || strings.HasPrefix(filepath.ToSlash(filePath), "/"):
+			problems = append(problems, fmt.Sprintf("file %s has an absolute path", filePath))
+		case !filepath.IsLocal(filePath):
+			problems = append(problems, fmt.Sprintf("file %s points outside the project directory", filePath))
+		}
+	}
+
+	listed := make(map[string]bool, len(spec.Order))
+	for _, filePath := range spec.Order {
+		if _, ok := spec.Files[filePath]; !ok {
+			problems = append(problems, fmt.Sprintf("order lists %s, which is not in files", filePath))
+		} else if listed[filePath] {
+			problems = append(problems, fmt.Sprintf("order lists %s more than once", filePath))
+		}
+		listed[filePath] = true
+	}
+	for _, filePath := range spec.ExcludeFromContext {
+		if _, ok := spec.Files[filePath]; !ok {
+			problems = append(problems, fmt.Sprintf("exclude_from_context lists %s, which is not in files", filePath))
+		}
+	}
+	problems = append(problems, dependencyProblems(spec)...)
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid project spec:\n- %s", strings.Join(problems, "\n- "))
+}
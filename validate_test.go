@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func validSpec() *ProjectSpec {
+	return &ProjectSpec{
+		Name:      "todo-api",
+		Type:      "api",
+		Framework: "gin",
+		Files:     map[string]string{"main.go": "Entry point"},
+	}
+}
+
+func TestValidateSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(spec *ProjectSpec)
+		problem string
+	}{
+		{"missing name", func(spec *ProjectSpec) { spec.Name = "" }, "name is empty"},
+		{"blank name", func(spec *ProjectSpec) { spec.Name = "  " }, "name is empty"},
+		{"dot name", func(spec *ProjectSpec) { spec.Name = "." }, `name "." is not a single directory name`},
+		{"parent name", func(spec *ProjectSpec) { spec.Name = ".." }, `name ".." is not a single directory name`},
+		{"nested name", func(spec *ProjectSpec) { spec.Name = "a/b" }, `name "a/b" is not a single directory name`},
+		{"missing type", func(spec *ProjectSpec) { spec.Type = "" }, "type is empty"},
+		{"missing framework", func(spec *ProjectSpec) { spec.Framework = "" }, "framework is empty"},
+		{"nil files", func(spec *ProjectSpec) { spec.Files = nil }, "no files are listed"},
+		{"no files", func(spec *ProjectSpec) { spec.Files = map[string]string{} }, "no files are listed"},
+		{"absolute path", func(spec *ProjectSpec) { spec.Files["/etc/passwd"] = "" }, "file /etc/passwd has an absolute path"},
+		{"escaping path", func(spec *ProjectSpec) { spec.Files["../x.go"] = "" }, "file ../x.go points outside the project directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := validSpec()
+			tt.modify(spec)
+			err := validateSpec(spec)
+			if err == nil {
+				t.Fatalf("validateSpec() = nil, want an error with %q", tt.problem)
+			}
+			if !strings.Contains(err.Error(), "- "+tt.problem+"\n") && !strings.HasSuffix(err.Error(), "- "+tt.problem) {
+				t.Errorf("validateSpec() = %q, want it to list %q", err, tt.problem)
+			}
+		})
+	}
+}
+
+func TestValidateSpecValid(t *testing.T) {
+	if err := validateSpec(validSpec()); err != nil {
+		t.Errorf("validateSpec() = %v, want nil", err)
+	}
+}
+
+func TestValidateSpecListsAllProblems(t *testing.T) {
+	err := validateSpec(&ProjectSpec{})
+	if err == nil {
+		t.Fatal("validateSpec() = nil, want an error")
+	}
+	for _, problem := range []string{"name is empty", "type is empty", "framework is empty", "no files are listed"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("validateSpec() = %q, want it to list %q", err, problem)
+		}
+	}
+}
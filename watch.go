@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/sha256"
+	"os"
+	"time"
+)
+
+// Timing of -watch: how often the spec file is checked, and how long it has
+// to stay unchanged before a save is acted on, so that editors writing a
+// file in several steps trigger a single regeneration.
+const (
+	watchInterval = 500 * time.Millisecond
+	watchDebounce = time.Second
+)
+
+// WatchSpec generates the project from the spec file and regenerates it
+// each time the file changes, until the agent's context is done. Runs are
+// incremental, so only files whose descriptions changed are regenerated.
+// load reads the spec; errors while loading or generating are reported and
+// watching continues.
+func (a *DevAgent) WatchSpec(path string, load func() (*ProjectSpec, error)) error {
+	a.Incremental = true
+
+	var lastSum [sha256.Size]byte
+	regenerate := func() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			a.Log.Errorf("%v", describeFSError("read spec file", path, err))
+			return
+		}
+		sum := sha256.Sum256(data)
+		if sum == lastSum {
+			a.Log.Debugf("%s was saved without changes", path)
+			return
+		}
+		lastSum = sum
+
+		spec, err := load()
+		if err != nil {
+			a.Log.Errorf("Failed to load project specification: %v", err)
+			return
+		}
+		a.Usage.Reset()
+		err = a.GenerateCode(spec)
+		a.Log.Infof("📊 Usage: %s", a.Usage.Summary())
+		if err != nil {
+			a.Log.Errorf("Failed to generate project: %v", err)
+		}
+	}
+
+	regenerate()
+	a.Log.Infof("👀 Watching %s for changes (press Ctrl+C to stop)", path)
+
+	var lastMod time.Time
+	var lastSize int64
+	var changedAt time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastMod, lastSize = info.ModTime(), info.Size()
+	}
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return a.ctx.Err()
+		case <-ticker.C:
+		}
+
+		// A missing file is usually an editor replacing it; keep waiting
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(lastMod) || info.Size() != lastSize {
+			lastMod, lastSize = info.ModTime(), info.Size()
+			changedAt = time.Now()
+			continue
+		}
+		if !changedAt.IsZero() && time.Since(changedAt) >= watchDebounce {
+			changedAt = time.Time{}
+			a.Log.Infof("🔄 %s changed, regenerating...", path)
+			regenerate()
+		}
+	}
+}